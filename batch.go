@@ -0,0 +1,387 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultBatchWorkers is the default number of concurrent batch workers.
+const DefaultBatchWorkers = 4
+
+// otherErrorClass is the summary key for errors which don't belong to any error class.
+const otherErrorClass = "other"
+
+// Job is the single capture of the batch.
+type Job struct {
+	// ID identifies the job in results
+	ID string
+
+	// URL is the URL to capture
+	URL string
+
+	// Filename is the output file. If it's empty then the screenshot is written to the path expanded from
+	// BatchParams.PathTemplate, or only returned in JobResult if there is no template
+	Filename string
+
+	// Options are the request options of the job
+	Options []Option
+}
+
+// JobResult is the outcome of the job.
+type JobResult struct {
+	Job Job
+
+	// Result is the captured screenshot, or nil if Err is not nil
+	Result *Result
+
+	// Filename is the name of the written file, which may differ from Job.Filename for OverwriteUnique
+	Filename string
+
+	// Err is the job error
+	Err error
+
+	// Elapsed is the time spent on the job
+	Elapsed time.Duration
+}
+
+// Progress is the batch progress reported after every job.
+type Progress struct {
+	// Total is the number of jobs, or -1 if it's unknown
+	Total int
+
+	// Done is the number of processed jobs
+	Done int
+
+	Succeeded int
+	Failed    int
+
+	// Last is the result of the job just processed
+	Last JobResult
+}
+
+// BatchSummary is the final report of the batch.
+type BatchSummary struct {
+	Total     int
+	Succeeded int
+	Failed    int
+
+	// FailedByClass is the number of failed jobs by error class (see ErrorClass).
+	// Errors which don't belong to any class are counted as "other"
+	FailedByClass map[string]int
+
+	// CreditsUsed is the number of API credits spent, one per successful capture made by the API.
+	// Screenshots served from the cache or shared with concurrent identical jobs are not counted
+	CreditsUsed int
+
+	// Elapsed is the batch duration
+	Elapsed time.Duration
+}
+
+// BatchParams is used to create Batch. None of parameters are mandatory.
+type BatchParams struct {
+	// Workers is the number of concurrent workers
+	// If it's zero then DefaultBatchWorkers is used
+	Workers int
+
+	// RateLimit is the maximum number of jobs started per second shared by all workers,
+	// in addition to the rate limit of the client
+	// If it's zero then jobs are not rate limited
+	RateLimit float64
+
+	// FileParams specifies how output files are written
+	FileParams FileParams
+
+	// PathTemplate is used to name the output files of jobs without Filename
+	// If it's nil then such jobs are not written to files
+	PathTemplate *PathTemplate
+
+	// OutputDir is the directory the paths expanded from PathTemplate are relative to
+	// If it's empty then they are relative to the working directory
+	OutputDir string
+
+	// OnProgress is called after every job in the order the jobs are finished. Calls are never concurrent,
+	// and a slow callback blocks neither Summary nor the other workers
+	OnProgress func(Progress)
+
+	// Manifest records the outcome of every job if it's not nil. See Batch.Resume
+	Manifest *Manifest
+
+	// MaxJobAttempts is the number of attempts per job across resumed runs
+	// If it's zero then failed jobs are retried on every run
+	MaxJobAttempts int
+}
+
+// Batch captures a list of URLs with a pool of workers. Batch is single-use.
+type Batch struct {
+	service ScreenshotAPIService
+	params  BatchParams
+	limiter *rateLimiter
+
+	mu          sync.Mutex
+	progress    Progress
+	summary     BatchSummary
+	started     time.Time
+	manifestErr error
+
+	// pending is the progress not yet passed to OnProgress, notifying is true while
+	// a worker is passing it. Both are guarded by mu
+	pending   []Progress
+	notifying bool
+}
+
+// NewBatch creates Batch which captures URLs with the specified service, e.g. Client.
+func NewBatch(service ScreenshotAPIService, params BatchParams) *Batch {
+	if params.Workers <= 0 {
+		params.Workers = DefaultBatchWorkers
+	}
+
+	b := &Batch{
+		service: service,
+		params:  params,
+		summary: BatchSummary{FailedByClass: map[string]int{}},
+	}
+
+	if params.RateLimit > 0 {
+		b.limiter = newRateLimiter(params.RateLimit, 1)
+	}
+
+	return b
+}
+
+// Start processes jobs from the channel until it's closed or ctx is done. It returns the channel of results
+// which is closed when all workers are finished. The caller must drain the results channel.
+func (b *Batch) Start(ctx context.Context, jobs <-chan Job) <-chan JobResult {
+	return b.start(ctx, jobs, -1)
+}
+
+// Run processes the jobs and returns the summary. Results are reported through BatchParams.OnProgress.
+// It returns ctx.Err() if the batch is cancelled.
+func (b *Batch) Run(ctx context.Context, jobs []Job) (*BatchSummary, error) {
+	ch := make(chan Job, len(jobs))
+	for _, job := range jobs {
+		ch <- job
+	}
+	close(ch)
+
+	for range b.start(ctx, ch, len(jobs)) {
+	}
+
+	summary := b.Summary()
+
+	return &summary, ctx.Err()
+}
+
+// Resume processes the jobs of BatchParams.Manifest which are not succeeded and have attempts left
+// (see Manifest.Pending), and records their outcomes in the manifest.
+func (b *Batch) Resume(ctx context.Context) (*BatchSummary, error) {
+	if b.params.Manifest == nil {
+		return nil, &ArgError{"Manifest", "can not be nil"}
+	}
+
+	summary, err := b.Run(ctx, b.params.Manifest.Pending(b.params.MaxJobAttempts))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.manifestErr != nil {
+		return summary, fmt.Errorf("cannot record manifest: %w", b.manifestErr)
+	}
+
+	return summary, err
+}
+
+// Summary returns the batch summary. It's final after the results channel is closed.
+func (b *Batch) Summary() BatchSummary {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	summary := b.summary
+	summary.FailedByClass = make(map[string]int, len(b.summary.FailedByClass))
+	for class, n := range b.summary.FailedByClass {
+		summary.FailedByClass[class] = n
+	}
+
+	return summary
+}
+
+// start runs the workers.
+func (b *Batch) start(ctx context.Context, jobs <-chan Job, total int) <-chan JobResult {
+	b.started = time.Now()
+	b.progress.Total = total
+
+	results := make(chan JobResult)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < b.params.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				var (
+					job Job
+					ok  bool
+				)
+
+				select {
+				case <-ctx.Done():
+					return
+				case job, ok = <-jobs:
+					if !ok {
+						return
+					}
+				}
+
+				res := b.process(ctx, job)
+				b.report(res)
+
+				// the consumer may stop draining the results after cancellation
+				select {
+				case results <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// process captures the single job.
+func (b *Batch) process(ctx context.Context, job Job) JobResult {
+	start := time.Now()
+
+	res := JobResult{Job: job}
+
+	if b.limiter != nil {
+		if res.Err = b.limiter.wait(ctx); res.Err != nil {
+			res.Elapsed = time.Since(start)
+			return res
+		}
+	}
+
+	filename := job.Filename
+	if filename == "" && b.params.PathTemplate != nil {
+		data, err := NewPathData(job.URL, start, job.Options...)
+		if err == nil {
+			data.ID = sanitizeSegment(job.ID)
+			filename, err = b.params.PathTemplate.Execute(data)
+		}
+		if err == nil && b.params.OutputDir != "" {
+			filename = filepath.Join(b.params.OutputDir, filepath.FromSlash(filename))
+		}
+		if err != nil {
+			res.Err = err
+			res.Elapsed = time.Since(start)
+			return res
+		}
+	}
+
+	opts := job.Options
+	if filename != "" {
+		opts = append(append([]Option{}, job.Options...), OptionImageOutputFormat("image"))
+	}
+
+	res.Result, res.Err = b.service.Capture(ctx, job.URL, opts...)
+
+	if res.Err == nil && filename != "" {
+		res.Filename, res.Err = WriteFile(filename, bytes.NewReader(res.Result.Body), b.params.FileParams)
+	}
+
+	res.Elapsed = time.Since(start)
+
+	return res
+}
+
+// report updates the progress and summary, and calls OnProgress.
+func (b *Batch) report(res JobResult) {
+	if !b.update(res) {
+		return
+	}
+
+	// the worker which queued the progress first passes it to OnProgress along with
+	// the progress queued by other workers meanwhile
+	for {
+		b.mu.Lock()
+		pending := b.pending
+		b.pending = nil
+		if len(pending) == 0 {
+			b.notifying = false
+		}
+		b.mu.Unlock()
+
+		if len(pending) == 0 {
+			return
+		}
+
+		for _, p := range pending {
+			b.params.OnProgress(p)
+		}
+	}
+}
+
+// update updates the progress and summary, records the result in the manifest and queues the progress
+// for OnProgress. It reports whether the caller has to pass the queued progress to OnProgress.
+func (b *Batch) update(res JobResult) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.progress.Done++
+	b.summary.Total++
+
+	if res.Err == nil {
+		b.progress.Succeeded++
+		b.summary.Succeeded++
+	} else {
+		b.progress.Failed++
+		b.summary.Failed++
+
+		class := otherErrorClass
+		if c := ErrorClass(res.Err); c != nil {
+			class = c.Error()
+		}
+		b.summary.FailedByClass[class]++
+	}
+
+	// cached and shared screenshots are made without spending API credits
+	if res.Result != nil && !res.Result.Cached && !res.Result.Shared {
+		b.summary.CreditsUsed++
+	}
+
+	b.summary.Elapsed = time.Since(b.started)
+
+	// jobs interrupted by cancellation don't spend the attempts budget
+	canceled := errors.Is(res.Err, context.Canceled) || errors.Is(res.Err, context.DeadlineExceeded)
+
+	if b.params.Manifest != nil && !canceled {
+		if err := b.params.Manifest.Record(res); err != nil && b.manifestErr == nil {
+			b.manifestErr = err
+		}
+	}
+
+	if b.params.OnProgress == nil {
+		return false
+	}
+
+	b.progress.Last = res
+	b.pending = append(b.pending, b.progress)
+
+	if b.notifying {
+		return false
+	}
+	b.notifying = true
+
+	return true
+}
@@ -0,0 +1,305 @@
+package screenshotapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newBatchAPI returns the client of the server which fails for URLs containing "nocredits" and "broken".
+func newBatchAPI() (*Client, *httptest.Server) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		target := req.URL.Query().Get("url")
+		switch {
+		case strings.Contains(target, "nocredits"):
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"code":403,"messages":"Access restricted. Check credits balance."}`))
+		case strings.Contains(target, "broken"):
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			_, _ = w.Write(pngImage(100, 100))
+		}
+	}))
+
+	apiURL, _ := url.Parse(server.URL)
+
+	return NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+	}), server
+}
+
+// imageService is the ScreenshotAPIService stand-in which captures every URL at once.
+type imageService struct {
+	ScreenshotAPIService
+
+	calls int32
+}
+
+// Capture returns the tiny PNG image.
+func (s *imageService) Capture(ctx context.Context, url string, opts ...Option) (*Result, error) {
+	atomic.AddInt32(&s.calls, 1)
+
+	return &Result{URL: url, Body: pngImage(1, 1), Format: FormatPNG}, nil
+}
+
+// TestBatchRun tests the Run function.
+func TestBatchRun(t *testing.T) {
+	api, server := newBatchAPI()
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	jobs := []Job{
+		{URL: "a.com", Filename: filepath.Join(dir, "a.png"), Options: []Option{OptionType("png")}},
+		{URL: "b.com", Filename: filepath.Join(dir, "b.png"), Options: []Option{OptionType("png")}},
+		{URL: "c.com"},
+		{URL: "nocredits.com"},
+		{URL: "broken.com"},
+		{URL: ""},
+	}
+
+	var (
+		mu       sync.Mutex
+		progress []Progress
+	)
+
+	batch := NewBatch(api, BatchParams{
+		Workers: 3,
+		OnProgress: func(p Progress) {
+			mu.Lock()
+			progress = append(progress, p)
+			mu.Unlock()
+		},
+	})
+
+	summary, err := batch.Run(context.Background(), jobs)
+	if err != nil {
+		t.Fatalf("Batch.Run() error = %v", err)
+	}
+
+	want := BatchSummary{
+		Total:     6,
+		Succeeded: 3,
+		Failed:    3,
+		FailedByClass: map[string]int{
+			ErrInsufficientCredits.Error(): 1,
+			ErrServerError.Error():         1,
+			otherErrorClass:                1,
+		},
+		CreditsUsed: 3,
+	}
+	summary.Elapsed = 0
+	if summary.Total != want.Total || summary.Succeeded != want.Succeeded || summary.Failed != want.Failed ||
+		summary.CreditsUsed != want.CreditsUsed || len(summary.FailedByClass) != len(want.FailedByClass) {
+		t.Errorf("Batch.Run() = %+v, want %+v", summary, want)
+	}
+	for class, n := range want.FailedByClass {
+		if summary.FailedByClass[class] != n {
+			t.Errorf("Batch.Run() failed by %s = %d, want %d", class, summary.FailedByClass[class], n)
+		}
+	}
+
+	if len(progress) != 6 || progress[5].Done != 6 || progress[5].Total != 6 {
+		t.Errorf("OnProgress calls = %d, last = %+v", len(progress), progress[len(progress)-1])
+	}
+
+	for _, name := range []string{"a.png", "b.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("output file: %v", err)
+		}
+	}
+}
+
+// TestBatchStart tests the Start function with cancellation.
+func TestBatchStart(t *testing.T) {
+	api, server := newBatchAPI()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := make(chan Job)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case jobs <- Job{URL: "whoisxmlapi.com"}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	batch := NewBatch(api, BatchParams{Workers: 2})
+
+	n := 0
+	for res := range batch.Start(ctx, jobs) {
+		if res.Err == nil && res.Result == nil {
+			t.Errorf("JobResult without result and error")
+		}
+		if n++; n == 5 {
+			cancel()
+		}
+	}
+
+	if summary := batch.Summary(); summary.Total < 5 || summary.Total > 7 {
+		t.Errorf("Batch.Summary() total = %d, want about 5", summary.Total)
+	}
+}
+
+// TestBatchCredits tests that cached and shared screenshots don't count as spent credits.
+func TestBatchCredits(t *testing.T) {
+	var calls int32
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		_, _ = w.Write(pngImage(100, 100))
+	}))
+	defer server.Close()
+
+	cache, err := NewCache(CacheParams{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiURL, _ := url.Parse(server.URL)
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+		Cache:                cache,
+	})
+
+	// the identical jobs are coalesced while the first call is blocked
+	jobs := []Job{{URL: "a.com"}, {URL: "a.com"}, {URL: "a.com"}, {URL: "b.com"}}
+
+	req, _, err := api.ScreenshotAPIService.(*screenshotAPIServiceOp).captureRequest("a.com",
+		OptionErrorsOutputFormat("JSON"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		waitWaiters(t, api.flights, flightKey(req), 3)
+		close(release)
+	}()
+
+	summary, err := NewBatch(api, BatchParams{Workers: len(jobs)}).Run(context.Background(), jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cached screenshots are free
+	again, err := NewBatch(api, BatchParams{}).Run(context.Background(), jobs[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("API calls = %d, want 2", got)
+	}
+	if summary.Succeeded != 4 || summary.CreditsUsed != 2 {
+		t.Errorf("Batch.Run() = %+v, want 4 succeeded and 2 credits used", summary)
+	}
+	if again.Succeeded != 2 || again.CreditsUsed != 0 {
+		t.Errorf("Batch.Run() = %+v, want 2 succeeded and 0 credits used", again)
+	}
+}
+
+// TestBatchSlowProgress tests that a slow OnProgress doesn't block the summary and other workers,
+// and the progress is still reported in order.
+func TestBatchSlowProgress(t *testing.T) {
+	const total = 8
+
+	jobs := make([]Job, total)
+	for i := range jobs {
+		jobs[i] = Job{URL: "whoisxmlapi.com"}
+	}
+
+	var (
+		release = make(chan struct{})
+		done    []int
+	)
+
+	batch := NewBatch(&imageService{}, BatchParams{
+		Workers: 4,
+		OnProgress: func(p Progress) {
+			if p.Done == 1 {
+				<-release
+			}
+			done = append(done, p.Done)
+		},
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		_, _ = batch.Run(context.Background(), jobs)
+	}()
+
+	// every job is counted while the first callback is still blocked
+	deadline := time.Now().Add(5 * time.Second)
+	for batch.Summary().Total != total {
+		if time.Now().After(deadline) {
+			t.Fatalf("Batch.Summary() total = %d, want %d", batch.Summary().Total, total)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	<-finished
+
+	for i, n := range done {
+		if n != i+1 {
+			t.Fatalf("OnProgress done = %v, want 1..%d in order", done, total)
+		}
+	}
+	if len(done) != total {
+		t.Errorf("OnProgress calls = %d, want %d", len(done), total)
+	}
+}
+
+// TestBatchStartAbandoned tests that workers exit after cancellation even if the results aren't drained.
+func TestBatchStartAbandoned(t *testing.T) {
+	service := &imageService{}
+
+	jobs := make(chan Job, 10)
+	for i := 0; i < cap(jobs); i++ {
+		jobs <- Job{URL: "whoisxmlapi.com"}
+	}
+
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	results := NewBatch(service, BatchParams{Workers: 2}).Start(ctx, jobs)
+
+	// both workers are blocked on sending their results
+	for atomic.LoadInt32(&service.calls) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines = %d, want %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, ok := <-results; ok {
+		t.Error("results channel is not closed")
+	}
+}
@@ -0,0 +1,380 @@
+package screenshotapi
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheFileExt is the extension of cache files.
+const cacheFileExt = ".cache"
+
+// CacheParams is used to create Cache.
+type CacheParams struct {
+	// Dir is the cache directory. It's created if it doesn't exist
+	Dir string
+
+	// TTL is the time cached responses are served for
+	// If it's zero then cached responses never expire
+	TTL time.Duration
+
+	// MaxSize is the maximum total size of cached responses in bytes. The least recently used
+	// responses are evicted when it's exceeded
+	// If it's zero then the size is not limited
+	MaxSize int64
+}
+
+// CacheStats is the cache statistics.
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+
+	// Entries is the number of cached responses
+	Entries int
+
+	// Size is the total size of cached responses in bytes
+	Size int64
+}
+
+// cacheMeta is the first line of the cache file.
+type cacheMeta struct {
+	Created    time.Time   `json:"created"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+}
+
+// cacheEntry is the cached response in the LRU list.
+type cacheEntry struct {
+	key  string
+	size int64
+}
+
+// Cache is the on-disk cache of successful Screenshot API responses keyed by the canonical target URL
+// and the request options. It's safe for concurrent use and can be shared by several clients.
+type Cache struct {
+	params CacheParams
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	stats   CacheStats
+}
+
+// NewCache creates Cache in the directory and indexes responses cached earlier.
+func NewCache(params CacheParams) (*Cache, error) {
+	if params.Dir == "" {
+		return nil, &ArgError{"Dir", "can not be empty"}
+	}
+
+	if err := os.MkdirAll(params.Dir, DefaultDirPerm); err != nil {
+		return nil, err
+	}
+
+	files, err := os.ReadDir(params.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type file struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+
+	var existing []file
+
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), cacheFileExt) {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		existing = append(existing, file{strings.TrimSuffix(f.Name(), cacheFileExt), info.Size(), info.ModTime()})
+	}
+
+	// the most recently used files go first
+	sort.Slice(existing, func(i, j int) bool {
+		return existing[i].modTime.After(existing[j].modTime)
+	})
+
+	c := &Cache{
+		params:  params,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+
+	for _, f := range existing {
+		c.entries[f.key] = c.lru.PushBack(&cacheEntry{key: f.key, size: f.size})
+		c.stats.Entries++
+		c.stats.Size += f.size
+	}
+
+	c.mu.Lock()
+	evicted := c.evict()
+	c.mu.Unlock()
+
+	c.removeFiles(evicted)
+
+	return c, nil
+}
+
+// Stats returns the cache statistics.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+// Purge removes all cached responses.
+func (c *Cache) Purge() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, el := range c.entries {
+		if err := os.Remove(c.path(key)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		c.remove(el)
+	}
+
+	return nil
+}
+
+// path returns the cache file path for the key.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.params.Dir, key+cacheFileExt)
+}
+
+// remove removes the entry from the index. The caller must hold the lock.
+func (c *Cache) remove(el *list.Element) {
+	entry := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.stats.Entries--
+	c.stats.Size -= entry.size
+}
+
+// evict removes the least recently used responses from the index until the size limit is satisfied,
+// and returns their keys. The caller must hold the lock and remove the files after releasing it.
+func (c *Cache) evict() []string {
+	var evicted []string
+
+	for c.params.MaxSize > 0 && c.stats.Size > c.params.MaxSize && c.lru.Len() > 0 {
+		el := c.lru.Back()
+		evicted = append(evicted, el.Value.(*cacheEntry).key)
+		c.remove(el)
+		c.stats.Evictions++
+	}
+
+	return evicted
+}
+
+// removeFiles removes the cache files of the keys.
+func (c *Cache) removeFiles(keys []string) {
+	for _, key := range keys {
+		_ = os.Remove(c.path(key))
+	}
+}
+
+// get returns the cached response for the key, or nil on a miss. The file is read without holding
+// the lock, a file removed or replaced meanwhile is a miss or a hit of the newer response.
+func (c *Cache) get(key string) *Response {
+	c.mu.Lock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	meta, body, err := c.read(key)
+	if err != nil || (c.params.TTL > 0 && time.Since(meta.Created) > c.params.TTL) {
+		c.mu.Lock()
+		// the entry may be replaced by put meanwhile
+		stale := c.entries[key] == el
+		if stale {
+			c.remove(el)
+		}
+		c.stats.Misses++
+		c.mu.Unlock()
+
+		if stale {
+			_ = os.Remove(c.path(key))
+		}
+
+		return nil
+	}
+
+	c.mu.Lock()
+	if c.entries[key] == el {
+		c.lru.MoveToFront(el)
+	}
+	c.stats.Hits++
+	c.mu.Unlock()
+
+	now := time.Now()
+	_ = os.Chtimes(c.path(key), now, now)
+
+	return &Response{
+		Response: &http.Response{
+			Status:        http.StatusText(meta.StatusCode),
+			StatusCode:    meta.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        meta.Header,
+			Body:          http.NoBody,
+			ContentLength: int64(len(body)),
+		},
+		Body:   body,
+		Cached: true,
+	}
+}
+
+// read reads the cache file.
+func (c *Cache) read(key string) (*cacheMeta, []byte, error) {
+	f, err := os.Open(c.path(key))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+
+	line, err := br.ReadBytes('\n')
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var meta cacheMeta
+	if err = json.Unmarshal(line, &meta); err != nil {
+		return nil, nil, err
+	}
+
+	body, err := io.ReadAll(br)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &meta, body, nil
+}
+
+// put stores the response for the key. The file is written atomically without holding the lock.
+func (c *Cache) put(key string, resp *Response) error {
+	line, err := json.Marshal(cacheMeta{
+		Created:    time.Now(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	})
+	if err != nil {
+		return err
+	}
+
+	data := append(append(line, '\n'), resp.Body...)
+
+	// WriteFile renames the complete temporary file to the cache file, so get never reads a partial one
+	if _, err = WriteFile(c.path(key), bytes.NewReader(data), FileParams{Perm: 0600}); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, size: int64(len(data))})
+	c.stats.Entries++
+	c.stats.Size += int64(len(data))
+
+	evicted := c.evict()
+	c.mu.Unlock()
+
+	c.removeFiles(evicted)
+
+	return nil
+}
+
+// cacheKey returns the cache key for the request: the hash of the canonical target URL and
+// the encoded options, excluding the API key.
+func cacheKey(query url.Values) string {
+	q := canonicalRequestQuery(query)
+	target := q.Get("url")
+	q.Del("url")
+
+	sum := sha256.Sum256([]byte(target + "\n" + q.Encode()))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalRequestQuery returns the copy of the query without the API key, with the canonical target URL
+// and cookies sorted by name, so identical requests have identical queries.
+func canonicalRequestQuery(query url.Values) url.Values {
+	q := url.Values{}
+	for name, values := range query {
+		switch name {
+		case "apiKey":
+		case "url":
+			q.Set(name, canonicalURL(query.Get(name)))
+		case "cookies":
+			q.Set(name, parseCookies(query.Get(name)).toString())
+		default:
+			q[name] = values
+		}
+	}
+
+	return q
+}
+
+// canonicalURL normalizes the target URL: the scheme and host are lowercased, default ports,
+// the empty path and the fragment are removed. URLs without a scheme keep it omitted.
+func canonicalURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+
+	withScheme := strings.Contains(rawURL, "://")
+	if !withScheme {
+		rawURL = "http://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+
+	if (u.Scheme == "http" && strings.HasSuffix(u.Host, ":80")) ||
+		(u.Scheme == "https" && strings.HasSuffix(u.Host, ":443")) {
+		u.Host = u.Host[:strings.LastIndex(u.Host, ":")]
+	}
+
+	if u.Path == "/" {
+		u.Path = ""
+	}
+
+	s := u.String()
+	if !withScheme {
+		s = strings.TrimPrefix(s, "http://")
+	}
+
+	return s
+}
@@ -0,0 +1,307 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCacheKey tests the cacheKey function.
+func TestCacheKey(t *testing.T) {
+	base := url.Values{"apiKey": {apiKey}, "url": {"example.com"}, "type": {"png"}, "width": {"800"}}
+
+	tests := []struct {
+		name  string
+		query url.Values
+		base  url.Values
+		same  bool
+	}{
+		{
+			name:  "different api key",
+			query: url.Values{"apiKey": {"at_other"}, "url": {"example.com"}, "type": {"png"}, "width": {"800"}},
+			same:  true,
+		},
+		{
+			name:  "uppercase host",
+			query: url.Values{"apiKey": {apiKey}, "url": {"EXAMPLE.com/"}, "type": {"png"}, "width": {"800"}},
+			same:  true,
+		},
+		{
+			name:  "fragment",
+			query: url.Values{"apiKey": {apiKey}, "url": {"example.com#top"}, "type": {"png"}, "width": {"800"}},
+			same:  true,
+		},
+		{
+			name:  "cookie order",
+			query: url.Values{"apiKey": {apiKey}, "url": {"example.com"}, "type": {"png"}, "width": {"800"}, "cookies": {"b=2;a=1"}},
+			base:  url.Values{"apiKey": {apiKey}, "url": {"example.com"}, "type": {"png"}, "width": {"800"}, "cookies": {"a=1;b=2"}},
+			same:  true,
+		},
+		{
+			name:  "different option",
+			query: url.Values{"apiKey": {apiKey}, "url": {"example.com"}, "type": {"png"}, "width": {"801"}},
+			same:  false,
+		},
+		{
+			name:  "different scheme",
+			query: url.Values{"apiKey": {apiKey}, "url": {"https://example.com"}, "type": {"png"}, "width": {"800"}},
+			same:  false,
+		},
+		{
+			name:  "different path",
+			query: url.Values{"apiKey": {apiKey}, "url": {"example.com/about"}, "type": {"png"}, "width": {"800"}},
+			same:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := base
+			if tt.base != nil {
+				b = tt.base
+			}
+			if got := cacheKey(tt.query) == cacheKey(b); got != tt.same {
+				t.Errorf("cacheKey() same = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+// TestCache tests serving responses from the cache.
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		if req.URL.Query().Get("url") == "error.com" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"messages":"Bad request."}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(pngImage(10, 10))
+	}))
+	defer server.Close()
+
+	newClient := func(cache *Cache) *Client {
+		apiURL, _ := url.Parse(server.URL)
+		return NewClient(apiKey, ClientParams{
+			HTTPClient:           server.Client(),
+			ScreenshotAPIBaseURL: apiURL,
+			Cache:                cache,
+		})
+	}
+
+	t.Run("hit", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+
+		cache, err := NewCache(CacheParams{Dir: t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		api := newClient(cache)
+
+		for i := 0; i < 3; i++ {
+			resp, err := api.GetRaw(ctx, "example.com", OptionType("png"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(resp.Body, pngImage(10, 10)) {
+				t.Fatalf("GetRaw() body mismatch on call %d", i)
+			}
+			if got := resp.Header.Get("Content-Type"); got != "image/png" {
+				t.Errorf("Content-Type = %q, want image/png", got)
+			}
+		}
+
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Errorf("API calls = %d, want 1", got)
+		}
+
+		stats := cache.Stats()
+		if stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+			t.Errorf("Stats() = %+v, want 2 hits, 1 miss, 1 entry", stats)
+		}
+
+		// the cache is shared between clients and survives restarts
+		reopened, err := NewCache(CacheParams{Dir: cache.params.Dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = newClient(reopened).GetRaw(ctx, "EXAMPLE.com", OptionType("png")); err != nil {
+			t.Fatal(err)
+		}
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Errorf("API calls after reopening = %d, want 1", got)
+		}
+	})
+
+	t.Run("cookies", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+
+		cache, err := NewCache(CacheParams{Dir: t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		api := newClient(cache)
+
+		cookies := Cookies{"session": "abc", "lang": "en", "theme": "dark"}
+		for i := 0; i < 10; i++ {
+			if _, err = api.GetRaw(ctx, "example.com", OptionCookies(cookies)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Errorf("API calls = %d, want 1", got)
+		}
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+
+		cache, err := NewCache(CacheParams{Dir: t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		api := newClient(cache)
+
+		for i := 0; i < 2; i++ {
+			if _, err := api.GetRaw(ctx, "error.com"); err == nil {
+				t.Fatal("GetRaw() error = nil")
+			}
+		}
+
+		if got := atomic.LoadInt32(&calls); got != 2 {
+			t.Errorf("API calls = %d, want 2", got)
+		}
+		if got := cache.Stats().Entries; got != 0 {
+			t.Errorf("Entries = %d, want 0", got)
+		}
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+
+		cache, err := NewCache(CacheParams{Dir: t.TempDir(), TTL: 50 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		api := newClient(cache)
+
+		if _, err = api.GetRaw(ctx, "example.com"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if _, err = api.GetRaw(ctx, "example.com"); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := atomic.LoadInt32(&calls); got != 2 {
+			t.Errorf("API calls = %d, want 2", got)
+		}
+	})
+
+	t.Run("lru eviction", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+
+		// the entry size depends on the length of the creation timestamp, so the cache is
+		// given room for two entries of any size but not for three
+		probe, err := NewCache(CacheParams{Dir: t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = newClient(probe).GetRaw(ctx, "a.com"); err != nil {
+			t.Fatal(err)
+		}
+		entrySize := probe.Stats().Size
+		maxSize := 2*entrySize + entrySize/2
+
+		atomic.StoreInt32(&calls, 0)
+
+		cache, err := NewCache(CacheParams{Dir: t.TempDir(), MaxSize: maxSize})
+		if err != nil {
+			t.Fatal(err)
+		}
+		api := newClient(cache)
+
+		for _, u := range []string{"a.com", "b.com", "a.com", "c.com"} {
+			if _, err = api.GetRaw(ctx, u); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		stats := cache.Stats()
+		if stats.Entries != 2 || stats.Evictions != 1 || stats.Size > maxSize {
+			t.Errorf("Stats() = %+v, want 2 entries and 1 eviction", stats)
+		}
+
+		// b.com is the least recently used one
+		before := atomic.LoadInt32(&calls)
+		for _, u := range []string{"a.com", "c.com"} {
+			if _, err = api.GetRaw(ctx, u); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := atomic.LoadInt32(&calls); got != before {
+			t.Errorf("API calls = %d, want %d", got, before)
+		}
+		if _, err = api.GetRaw(ctx, "b.com"); err != nil {
+			t.Fatal(err)
+		}
+		if got := atomic.LoadInt32(&calls); got != before+1 {
+			t.Errorf("API calls = %d, want %d", got, before+1)
+		}
+	})
+}
+
+// TestCacheConcurrent tests that concurrent reads never see partial responses while they are replaced
+// and evicted.
+func TestCacheConcurrent(t *testing.T) {
+	cache, err := NewCache(CacheParams{Dir: t.TempDir(), MaxSize: 4 << 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				key := "key" + strconv.Itoa(j%4)
+				body := bytes.Repeat([]byte{byte('a' + i)}, 1<<10)
+
+				if err := cache.put(key, &Response{Response: &http.Response{StatusCode: 200}, Body: body}); err != nil {
+					t.Error(err)
+					return
+				}
+
+				resp := cache.get(key)
+				if resp != nil && (len(resp.Body) != len(body) || bytes.Count(resp.Body, resp.Body[:1]) != len(body)) {
+					t.Errorf("cache.get() returned a partial or mixed body of %d bytes", len(resp.Body))
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if stats := cache.Stats(); stats.Size > 4<<10 || stats.Hits == 0 {
+		t.Errorf("Cache.Stats() = %+v", stats)
+	}
+}
@@ -0,0 +1,158 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Result is the captured screenshot with its metadata.
+type Result struct {
+	// URL is the captured URL
+	URL string
+
+	// Body is the screenshot data
+	Body []byte
+
+	// ContentType is the value of the Content-Type response header,
+	// or the MIME type declared by the decoded data URI
+	ContentType string
+
+	// Format is the screenshot format detected by the magic bytes of Body
+	Format ImageFormat
+
+	// Width and Height are the image dimensions in pixels. Both are zero for PDF documents
+	Width  int
+	Height int
+
+	// Pages is the number of pages of the PDF document. It's zero for images
+	Pages int
+
+	// Size is the size of Body in bytes
+	Size int
+
+	// SHA256 is the hex-encoded SHA-256 checksum of Body
+	SHA256 string
+
+	// Elapsed is the time spent on the request including retries
+	Elapsed time.Duration
+
+	// Options are the query parameters the request was made with, except the API key
+	Options url.Values
+
+	// Header is the HTTP response header
+	Header http.Header
+
+	// Cached is true if the screenshot is served from ClientParams.Cache
+	Cached bool
+
+	// Shared is true if the screenshot is made for a concurrent identical request
+	Shared bool
+}
+
+// newResult creates the Result from the Screenshot API response.
+func newResult(url string, resp *Response, elapsed time.Duration) *Result {
+	sum := sha256.Sum256(resp.Body)
+
+	result := &Result{
+		URL:         url,
+		Body:        resp.Body,
+		ContentType: resp.Header.Get("Content-Type"),
+		Format:      DetectFormat(resp.Body),
+		Size:        len(resp.Body),
+		SHA256:      hex.EncodeToString(sum[:]),
+		Elapsed:     elapsed,
+		Header:      resp.Header,
+		Cached:      resp.Cached,
+		Shared:      resp.Shared,
+	}
+
+	if resp.MediaType != "" {
+		result.ContentType = resp.MediaType
+	}
+
+	if resp.Request != nil && resp.Request.URL != nil {
+		result.Options = resp.Request.URL.Query()
+		result.Options.Del("apiKey")
+		result.Options.Del("url")
+	}
+
+	switch result.Format {
+	case FormatJPEG, FormatPNG:
+		result.Width, result.Height, _ = imageSize(resp.Body)
+	case FormatPDF:
+		result.Pages = pdfPageCount(resp.Body)
+	}
+
+	return result
+}
+
+// Capture captures a screenshot and returns it with metadata, or returns a parsed Screenshot API error.
+func (service screenshotAPIServiceOp) Capture(ctx context.Context, url string, opts ...Option) (*Result, error) {
+	optsJSON := make([]Option, 0, len(opts)+1)
+	optsJSON = append(optsJSON, OptionErrorsOutputFormat("JSON"))
+	optsJSON = append(optsJSON, opts...)
+
+	start := time.Now()
+
+	resp, err := service.request(ctx, url, optsJSON...)
+	if err != nil {
+		return nil, err
+	}
+
+	// non-2xx responses carry the body, headers and request ID, and unwrap to *ErrorMessage
+	if respErr := checkResponse(resp.Response, resp.Body); respErr != nil {
+		return nil, respErr
+	}
+
+	if msg := parseErrorMessage(resp.Body); msg != nil {
+		return nil, msg
+	}
+
+	return newResult(url, resp, time.Since(start)), nil
+}
+
+// CaptureTo captures a screenshot and stores it in the sink under the key. The stored object has
+// the content type of the screenshot and the url and sha256 metadata fields.
+func (service screenshotAPIServiceOp) CaptureTo(
+	ctx context.Context,
+	url string,
+	sink Sink,
+	key string,
+	opts ...Option,
+) (*Result, error) {
+	if sink == nil {
+		return nil, &ArgError{"sink", "can not be nil"}
+	}
+	if _, err := cleanKey(key); err != nil {
+		return nil, &ArgError{"key", err.Error()}
+	}
+
+	optsImage := make([]Option, 0, len(opts)+1)
+	optsImage = append(optsImage, opts...)
+	optsImage = append(optsImage, OptionImageOutputFormat("image"))
+
+	res, err := service.Capture(ctx, url, optsImage...)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := SinkMetadata{
+		ContentType: res.ContentType,
+		Fields: map[string]string{
+			"url":    url,
+			"sha256": res.SHA256,
+		},
+	}
+
+	if err = sink.Put(ctx, key, bytes.NewReader(res.Body), meta); err != nil {
+		return nil, fmt.Errorf("cannot store screenshot: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,93 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// pngImage returns the PNG image of the specified size.
+func pngImage(width, height int) []byte {
+	var b bytes.Buffer
+
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		panic(err)
+	}
+
+	return b.Bytes()
+}
+
+// TestScreenshotAPICapture tests the Capture function.
+func TestScreenshotAPICapture(t *testing.T) {
+	const pdf = "%PDF-1.4\n1 0 obj << /Type /Pages /Kids [2 0 R 3 0 R] /Count 2 >> endobj\n" +
+		"2 0 obj << /Type /Page >> endobj\n3 0 obj << /Type/Page >> endobj\n%%EOF"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Query().Get("type") {
+		case "pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte(pdf))
+		default:
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(pngImage(320, 200))
+		}
+	}))
+	defer server.Close()
+
+	apiURL, _ := url.Parse(server.URL)
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+	})
+
+	tests := []struct {
+		name       string
+		option     Option
+		wantFormat ImageFormat
+		wantWidth  int
+		wantHeight int
+		wantPages  int
+	}{
+		{
+			name:       "png",
+			option:     OptionType("png"),
+			wantFormat: FormatPNG,
+			wantWidth:  320,
+			wantHeight: 200,
+		},
+		{
+			name:       "pdf",
+			option:     OptionType("pdf"),
+			wantFormat: FormatPDF,
+			wantPages:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := api.Capture(context.Background(), "whoisxmlapi.com", tt.option)
+			if err != nil {
+				t.Fatalf("ScreenshotAPI.Capture() error = %v", err)
+			}
+
+			if res.Format != tt.wantFormat || res.Width != tt.wantWidth || res.Height != tt.wantHeight ||
+				res.Pages != tt.wantPages {
+				t.Errorf("ScreenshotAPI.Capture() = %v %dx%d %d pages, want %v %dx%d %d pages",
+					res.Format, res.Width, res.Height, res.Pages,
+					tt.wantFormat, tt.wantWidth, tt.wantHeight, tt.wantPages)
+			}
+
+			if res.Size != len(res.Body) || len(res.SHA256) != 64 {
+				t.Errorf("ScreenshotAPI.Capture() size = %d, sha256 = %s", res.Size, res.SHA256)
+			}
+
+			if res.Options.Get("type") != string(tt.wantFormat) || res.Options.Get("apiKey") != "" {
+				t.Errorf("ScreenshotAPI.Capture() options = %v", res.Options)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package screenshotapi
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// CaptureOptions is the typed representation of the request options. Every field mirrors
+// the corresponding Option function, the zero value of a field means the option is not set.
+// The struct can be stored in JSON or YAML job definitions.
+type CaptureOptions struct {
+	// ErrorsOutputFormat mirrors OptionErrorsOutputFormat
+	ErrorsOutputFormat string `json:"errorsOutputFormat,omitempty" yaml:"errorsOutputFormat,omitempty"`
+
+	// ImageOutputFormat mirrors OptionImageOutputFormat
+	ImageOutputFormat string `json:"imageOutputFormat,omitempty" yaml:"imageOutputFormat,omitempty"`
+
+	// Credits mirrors OptionCredits
+	Credits string `json:"credits,omitempty" yaml:"credits,omitempty"`
+
+	// Type mirrors OptionType
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// Quality mirrors OptionQuality
+	Quality int `json:"quality,omitempty" yaml:"quality,omitempty"`
+
+	// Width mirrors OptionWidth
+	Width int `json:"width,omitempty" yaml:"width,omitempty"`
+
+	// Height mirrors OptionHeight
+	Height int `json:"height,omitempty" yaml:"height,omitempty"`
+
+	// ThumbWidth mirrors OptionThumbWidth
+	ThumbWidth int `json:"thumbWidth,omitempty" yaml:"thumbWidth,omitempty"`
+
+	// Mode mirrors OptionMode
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+
+	// Scroll mirrors OptionScroll
+	Scroll bool `json:"scroll,omitempty" yaml:"scroll,omitempty"`
+
+	// ScrollPosition mirrors OptionScrollPosition
+	ScrollPosition string `json:"scrollPosition,omitempty" yaml:"scrollPosition,omitempty"`
+
+	// FullPage mirrors OptionFullPage
+	FullPage bool `json:"fullPage,omitempty" yaml:"fullPage,omitempty"`
+
+	// NoJs mirrors OptionNoJs
+	NoJs bool `json:"noJs,omitempty" yaml:"noJs,omitempty"`
+
+	// Delay mirrors OptionDelay. It's a pointer because zero is a valid delay
+	Delay *int `json:"delay,omitempty" yaml:"delay,omitempty"`
+
+	// Timeout mirrors OptionTimeout
+	Timeout int `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+
+	// Scale mirrors OptionScale
+	Scale float64 `json:"scale,omitempty" yaml:"scale,omitempty"`
+
+	// Retina mirrors OptionRetina
+	Retina bool `json:"retina,omitempty" yaml:"retina,omitempty"`
+
+	// UA mirrors OptionUA
+	UA string `json:"ua,omitempty" yaml:"ua,omitempty"`
+
+	// Cookies mirrors OptionCookies
+	Cookies Cookies `json:"cookies,omitempty" yaml:"cookies,omitempty"`
+
+	// Mobile mirrors OptionMobile
+	Mobile bool `json:"mobile,omitempty" yaml:"mobile,omitempty"`
+
+	// TouchScreen mirrors OptionTouchScreen
+	TouchScreen bool `json:"touchScreen,omitempty" yaml:"touchScreen,omitempty"`
+
+	// Landscape mirrors OptionLandscape
+	Landscape bool `json:"landscape,omitempty" yaml:"landscape,omitempty"`
+
+	// FailOnHostnameChange mirrors OptionFailOnHostnameChange
+	FailOnHostnameChange bool `json:"failOnHostnameChange,omitempty" yaml:"failOnHostnameChange,omitempty"`
+}
+
+// Options returns the Option functions for the fields which are set.
+func (o *CaptureOptions) Options() []Option {
+	var opts []Option
+
+	if o.ErrorsOutputFormat != "" {
+		opts = append(opts, OptionErrorsOutputFormat(o.ErrorsOutputFormat))
+	}
+	if o.ImageOutputFormat != "" {
+		opts = append(opts, OptionImageOutputFormat(o.ImageOutputFormat))
+	}
+	if o.Credits != "" {
+		opts = append(opts, OptionCredits(o.Credits))
+	}
+	if o.Type != "" {
+		opts = append(opts, OptionType(o.Type))
+	}
+	if o.Quality != 0 {
+		opts = append(opts, OptionQuality(o.Quality))
+	}
+	if o.Width != 0 {
+		opts = append(opts, OptionWidth(o.Width))
+	}
+	if o.Height != 0 {
+		opts = append(opts, OptionHeight(o.Height))
+	}
+	if o.ThumbWidth != 0 {
+		opts = append(opts, OptionThumbWidth(o.ThumbWidth))
+	}
+	if o.Mode != "" {
+		opts = append(opts, OptionMode(o.Mode))
+	}
+	if o.Scroll {
+		opts = append(opts, OptionScroll(o.Scroll))
+	}
+	if o.ScrollPosition != "" {
+		opts = append(opts, OptionScrollPosition(o.ScrollPosition))
+	}
+	if o.FullPage {
+		opts = append(opts, OptionFullPage(o.FullPage))
+	}
+	if o.NoJs {
+		opts = append(opts, OptionNoJs(o.NoJs))
+	}
+	if o.Delay != nil {
+		opts = append(opts, OptionDelay(*o.Delay))
+	}
+	if o.Timeout != 0 {
+		opts = append(opts, OptionTimeout(o.Timeout))
+	}
+	if o.Scale != 0 {
+		opts = append(opts, OptionScale(o.Scale))
+	}
+	if o.Retina {
+		opts = append(opts, OptionRetina(o.Retina))
+	}
+	if o.UA != "" {
+		opts = append(opts, OptionUA(o.UA))
+	}
+	if o.Cookies != nil {
+		opts = append(opts, OptionCookies(o.Cookies))
+	}
+	if o.Mobile {
+		opts = append(opts, OptionMobile(o.Mobile))
+	}
+	if o.TouchScreen {
+		opts = append(opts, OptionTouchScreen(o.TouchScreen))
+	}
+	if o.Landscape {
+		opts = append(opts, OptionLandscape(o.Landscape))
+	}
+	if o.FailOnHostnameChange {
+		opts = append(opts, OptionFailOnHostnameChange(o.FailOnHostnameChange))
+	}
+
+	return opts
+}
+
+// Values returns the query parameters for the options, or the first invalid option error.
+func (o *CaptureOptions) Values() (url.Values, error) {
+	v := url.Values{}
+
+	for _, opt := range o.Options() {
+		if err := opt(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// ParseCaptureOptions parses the query parameters into CaptureOptions. Parameters not related to options,
+// such as apiKey and url, are ignored. Every value is validated by the corresponding Option function,
+// and thumbWidth is checked against width as it is for every request.
+func ParseCaptureOptions(v url.Values) (*CaptureOptions, error) {
+	var (
+		o   CaptureOptions
+		err error
+	)
+
+	o.ErrorsOutputFormat = v.Get("errorsOutputFormat")
+	o.ImageOutputFormat = v.Get("imageOutputFormat")
+	o.Credits = v.Get("credits")
+	o.Type = v.Get("type")
+	o.Mode = v.Get("mode")
+	o.ScrollPosition = v.Get("scrollPosition")
+	o.UA = v.Get("ua")
+
+	for _, f := range []struct {
+		name string
+		dst  *int
+	}{
+		{"quality", &o.Quality},
+		{"width", &o.Width},
+		{"height", &o.Height},
+		{"thumbWidth", &o.ThumbWidth},
+		{"timeout", &o.Timeout},
+	} {
+		if *f.dst, err = parseIntValue(v, f.name); err != nil {
+			return nil, err
+		}
+	}
+
+	if v.Get("delay") != "" {
+		delay, err := parseIntValue(v, "delay")
+		if err != nil {
+			return nil, err
+		}
+		o.Delay = &delay
+	}
+
+	if s := v.Get("scale"); s != "" {
+		if o.Scale, err = strconv.ParseFloat(s, 64); err != nil {
+			return nil, &ArgError{"scale", "must be a number"}
+		}
+	}
+
+	for _, f := range []struct {
+		name string
+		dst  *bool
+	}{
+		{"scroll", &o.Scroll},
+		{"fullPage", &o.FullPage},
+		{"noJs", &o.NoJs},
+		{"retina", &o.Retina},
+		{"mobile", &o.Mobile},
+		{"touchScreen", &o.TouchScreen},
+		{"landscape", &o.Landscape},
+		{"failOnHostnameChange", &o.FailOnHostnameChange},
+	} {
+		if *f.dst, err = parseBoolValue(v, f.name); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := v["cookies"]; ok {
+		o.Cookies = parseCookies(v.Get("cookies"))
+	}
+
+	values, err := o.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	if argErr := thumbWidthRule(values); argErr != nil {
+		return nil, argErr
+	}
+
+	return &o, nil
+}
+
+// parseIntValue parses the integer query parameter. A missing parameter is parsed as zero.
+func parseIntValue(v url.Values, name string) (int, error) {
+	s := v.Get(name)
+	if s == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, &ArgError{name, "must be an integer"}
+	}
+
+	return n, nil
+}
+
+// parseBoolValue parses the boolean query parameter. A missing parameter is parsed as false.
+func parseBoolValue(v url.Values, name string) (bool, error) {
+	s := v.Get(name)
+	if s == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, &ArgError{name, "must be true | false"}
+	}
+
+	return b, nil
+}
+
+// Validate checks the options and the relationships between them, and reports all problems at once.
+// See ValidateOptions.
+func (o *CaptureOptions) Validate() error {
+	return ValidateOptions(o.Options()...)
+}
@@ -0,0 +1,129 @@
+package screenshotapi
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// TestCaptureOptions tests the round trip between CaptureOptions, Option functions and query parameters.
+func TestCaptureOptions(t *testing.T) {
+	delay := 0
+
+	opts := &CaptureOptions{
+		ErrorsOutputFormat:   "XML",
+		ImageOutputFormat:    "base64",
+		Credits:              "DRS",
+		Type:                 "jpg",
+		Quality:              50,
+		Width:                1024,
+		Height:               768,
+		ThumbWidth:           200,
+		Mode:                 "slow",
+		Scroll:               true,
+		ScrollPosition:       "bottom",
+		FullPage:             true,
+		NoJs:                 true,
+		Delay:                &delay,
+		Timeout:              15000,
+		Scale:                1.5,
+		Retina:               true,
+		UA:                   "UA",
+		Cookies:              Cookies{"name1": "value1", "name2": "value2"},
+		Mobile:               true,
+		TouchScreen:          true,
+		Landscape:            true,
+		FailOnHostnameChange: true,
+	}
+
+	if n, want := len(opts.Options()), reflect.TypeOf(*opts).NumField(); n != want {
+		t.Errorf("CaptureOptions.Options() returned %d options, want %d", n, want)
+	}
+
+	v, err := opts.Values()
+	if err != nil {
+		t.Fatalf("CaptureOptions.Values() error = %v", err)
+	}
+
+	v.Set("apiKey", apiKey)
+	v.Set("url", "whoisxmlapi.com")
+
+	got, err := ParseCaptureOptions(v)
+	if err != nil {
+		t.Fatalf("ParseCaptureOptions() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, opts) {
+		t.Errorf("ParseCaptureOptions() = %+v, want %+v", got, opts)
+	}
+
+	raw, err := json.Marshal(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded CaptureOptions
+	if err = json.Unmarshal(raw, &decoded); err != nil || !reflect.DeepEqual(&decoded, opts) {
+		t.Errorf("json round trip = %+v, %v, want %+v", decoded, err, opts)
+	}
+}
+
+// TestParseCaptureOptions tests the ParseCaptureOptions function.
+func TestParseCaptureOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    *CaptureOptions
+		wantErr string
+	}{
+		{
+			name:  "empty",
+			query: "",
+			want:  &CaptureOptions{},
+		},
+		{
+			name:  "lowercase values",
+			query: "type=png&credits=drs&fullPage=true",
+			want:  &CaptureOptions{Type: "png", Credits: "drs", FullPage: true},
+		},
+		{
+			name:    "not an integer",
+			query:   "width=wide",
+			wantErr: `invalid argument: "width" must be an integer`,
+		},
+		{
+			name:    "not a boolean",
+			query:   "mobile=yes",
+			wantErr: `invalid argument: "mobile" must be true | false`,
+		},
+		{
+			name:    "out of range",
+			query:   "quality=100",
+			wantErr: `invalid argument: "quality" must be between 40 and 99`,
+		},
+		{
+			name:    "thumbnail wider than screenshot",
+			query:   "width=400&thumbWidth=500",
+			wantErr: `invalid argument: "thumbWidth" must be between 50 and width param value`,
+		},
+		{
+			name:    "thumbnail wider than default width",
+			query:   "thumbWidth=1000",
+			wantErr: `invalid argument: "thumbWidth" must be between 50 and width param value`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, _ := url.ParseQuery(tt.query)
+
+			got, err := ParseCaptureOptions(v)
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("ParseCaptureOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCaptureOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
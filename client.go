@@ -27,6 +27,37 @@ type ClientParams struct {
 
 	// ScreenshotAPIBaseURL is the endpoint for 'Screenshot API' service
 	ScreenshotAPIBaseURL *url.URL
+
+	// RetryPolicy specifies how failed requests are retried
+	// If it's nil then every request is made exactly once
+	RetryPolicy *RetryPolicy
+
+	// RateLimit is the maximum number of requests per second made by the client
+	// If it's zero then requests are not rate limited
+	RateLimit float64
+
+	// RateBurst is the number of requests which can be made at once before RateLimit applies
+	// Values less than 1 are treated as 1
+	RateBurst int
+
+	// MaxConcurrentRequests is the maximum number of requests in flight
+	// If it's zero then the number of concurrent requests is not limited
+	MaxConcurrentRequests int
+
+	// FileParams specifies how Get writes output files
+	FileParams FileParams
+
+	// APIKeyHeader is the name of the HTTP header used to send the API key, e.g. X-Authentication-Token
+	// If it's empty then the API key is sent in the apiKey query parameter
+	APIKeyHeader string
+
+	// ValidateBody if true, Get, GetRaw and Capture check that the response body is a complete image
+	// of the type requested with OptionType, and fail with *ValidationError otherwise
+	ValidateBody bool
+
+	// Cache serves Get, GetRaw and Capture from the on-disk cache of successful responses
+	// If it's nil then responses are not cached
+	Cache *Cache
 }
 
 // NewBasicClient creates Client with recommended parameters.
@@ -52,9 +83,23 @@ func NewClient(apiKey string, params ClientParams) *Client {
 	}
 
 	client := &Client{
-		client:    httpClient,
-		userAgent: userAgent,
-		apiKey:    apiKey,
+		client:       httpClient,
+		userAgent:    userAgent,
+		apiKey:       apiKey,
+		retryPolicy:  params.RetryPolicy,
+		fileParams:   params.FileParams,
+		apiKeyHeader: params.APIKeyHeader,
+		validateBody: params.ValidateBody,
+		cache:        params.Cache,
+		flights:      &flightGroup{},
+	}
+
+	if params.RateLimit > 0 {
+		client.limiter = newRateLimiter(params.RateLimit, params.RateBurst)
+	}
+
+	if params.MaxConcurrentRequests > 0 {
+		client.inFlight = make(chan struct{}, params.MaxConcurrentRequests)
 	}
 
 	client.ScreenshotAPIService = &screenshotAPIServiceOp{client: client, baseURL: apiBaseURL}
@@ -69,6 +114,17 @@ type Client struct {
 	userAgent string
 	apiKey    string
 
+	retryPolicy *RetryPolicy
+	limiter     *rateLimiter
+	inFlight    chan struct{}
+	fileParams  FileParams
+
+	apiKeyHeader string
+	validateBody bool
+
+	cache   *Cache
+	flights *flightGroup
+
 	// ScreenshotAPI is an interface for Screenshot API
 	ScreenshotAPIService
 }
@@ -93,11 +149,9 @@ func (c *Client) NewRequest(method string, u *url.URL, body io.Reader) (*http.Re
 
 // Do sends the API request and returns the API response.
 func (c *Client) Do(ctx context.Context, req *http.Request, v io.Writer) (response *http.Response, err error) {
-	req = req.WithContext(ctx)
-
-	resp, err := c.client.Do(req)
+	resp, err := c.send(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("cannot execute request: %w", err)
+		return nil, err
 	}
 
 	defer func() {
@@ -114,10 +168,35 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v io.Writer) (respon
 	return resp, err
 }
 
+// send sends the API request and returns the API response with the unread body.
+func (c *Client) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("cannot execute request: %w", redactError(err))
+	}
+
+	resp.Request = redactRequest(resp.Request, c.apiKeyHeader)
+
+	return resp, nil
+}
+
+// requestIDHeaders are the response headers which may carry the request ID.
+var requestIDHeaders = []string{"X-Request-Id", "Request-Id", "X-Correlation-Id"}
+
 // ErrorResponse is returned when the response status code is not 2xx.
 type ErrorResponse struct {
 	Response *http.Response
 	Message  string
+
+	// Body is the raw response body
+	Body []byte
+
+	// ErrorMessage is the Screenshot API error parsed from Body in JSON or XML format,
+	// or nil if Body doesn't contain it
+	ErrorMessage *ErrorMessage
+
+	// RequestID is the request ID reported by the API, if any
+	RequestID string
 }
 
 // Error returns error message as a string.
@@ -129,14 +208,36 @@ func (e *ErrorResponse) Error() string {
 	return "API failed with status code: " + strconv.Itoa(e.Response.StatusCode)
 }
 
+// Unwrap returns the parsed Screenshot API error, so it can be extracted with errors.As.
+func (e *ErrorResponse) Unwrap() error {
+	if e.ErrorMessage == nil {
+		return nil
+	}
+
+	return e.ErrorMessage
+}
+
 // checkResponse checks if the response status code is not 2xx.
-func checkResponse(r *http.Response) error {
+func checkResponse(r *http.Response, body []byte) error {
 	if c := r.StatusCode; c >= 200 && c <= 299 {
 		return nil
 	}
 
 	var errorResponse = ErrorResponse{
 		Response: r,
+		Body:     body,
+	}
+
+	for _, h := range requestIDHeaders {
+		if id := r.Header.Get(h); id != "" {
+			errorResponse.RequestID = id
+			break
+		}
+	}
+
+	if msg := parseErrorMessage(body); msg != nil {
+		errorResponse.ErrorMessage = msg
+		errorResponse.Message = msg.Message
 	}
 
 	return &errorResponse
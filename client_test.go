@@ -2,9 +2,14 @@ package screenshotapi
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strconv"
 	"testing"
 )
@@ -170,7 +175,7 @@ func TestScreenshotAPIGet(t *testing.T) {
 				},
 			},
 			want:    false,
-			wantErr: "API error: [499] Test error message.",
+			wantErr: "API failed with status code: 499 (Test error message.)",
 		},
 		{
 			name: "unparsable response",
@@ -242,6 +247,36 @@ func TestScreenshotAPIGet(t *testing.T) {
 	}
 }
 
+// TestScreenshotAPIGetFile tests the GetFile function.
+func TestScreenshotAPIGetFile(t *testing.T) {
+	ctx := context.Background()
+
+	server := dummyServer("\xff\xd8\xff\xe0image", "", "")
+	defer server.Close()
+
+	api := newAPI(server, pathScreenshotAPIResponseOK)
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "shots", "a.jpg")
+
+	params := FileParams{Overwrite: OverwriteUnique, CreateDirs: true}
+
+	for _, want := range []string{filename, filepath.Join(dir, "shots", "a-1.jpg")} {
+		name, err := api.GetFile(ctx, "whoisxmlapi.com", filename, params)
+		if err != nil {
+			t.Fatalf("ScreenshotAPI.GetFile() error = %v", err)
+		}
+		if name != want {
+			t.Errorf("ScreenshotAPI.GetFile() name = %v, want %v", name, want)
+		}
+	}
+
+	_, err := api.GetFile(ctx, "whoisxmlapi.com", filename, FileParams{Overwrite: OverwriteNever})
+	if !errors.Is(err, os.ErrExist) {
+		t.Errorf("ScreenshotAPI.GetFile() error = %v, want %v", err, os.ErrExist)
+	}
+}
+
 // TestScreenshotAPIGetRaw tests the GetRaw function.
 func TestScreenshotAPIGetRaw(t *testing.T) {
 	checkResultRaw := func(res []byte) bool {
@@ -345,7 +380,7 @@ func TestScreenshotAPIGetRaw(t *testing.T) {
 					OptionImageOutputFormat("base64"),
 				},
 			},
-			wantErr: "API failed with status code: 499",
+			wantErr: "API failed with status code: 499 (Test error message.)",
 		},
 		{
 			name: "invalid argument",
@@ -376,3 +411,80 @@ func TestScreenshotAPIGetRaw(t *testing.T) {
 		})
 	}
 }
+
+// TestErrorResponse tests that ErrorResponse carries the parsed API error body.
+func TestErrorResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		errResp string
+		want    *ErrorMessage
+	}{
+		{
+			name:    "JSON error",
+			errResp: `{"code":499,"messages":"Test error message."}`,
+			want:    &ErrorMessage{Code: 499, Message: "Test error message."},
+		},
+		{
+			name:    "XML error",
+			errResp: `<?xml version="1.0" encoding="utf-8"?><ErrorMessage><code>499</code><messages>Test error message.</messages></ErrorMessage>`,
+			want:    &ErrorMessage{Code: 499, Message: "Test error message."},
+		},
+		{
+			name:    "unparsable error",
+			errResp: `Internal error`,
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := dummyServer("", "", tt.errResp)
+			defer server.Close()
+
+			api := newAPI(server, pathScreenshotAPIResponseError)
+			ctx := context.Background()
+
+			// every capture function reports non-2xx responses the same way
+			calls := []struct {
+				name string
+				call func() error
+			}{
+				{"GetRaw", func() error {
+					_, err := api.GetRaw(ctx, "whoisxmlapi.com")
+					return err
+				}},
+				{"Get", func() error {
+					return api.Get(ctx, "whoisxmlapi.com", filepath.Join(t.TempDir(), "filename.jpg"))
+				}},
+				{"Capture", func() error {
+					_, err := api.Capture(ctx, "whoisxmlapi.com")
+					return err
+				}},
+				{"GetTo", func() error {
+					return api.GetTo(ctx, "whoisxmlapi.com", io.Discard)
+				}},
+			}
+
+			for _, c := range calls {
+				err := c.call()
+
+				var errResp *ErrorResponse
+				if !errors.As(err, &errResp) {
+					t.Fatalf("ScreenshotAPI.%s() error = %v, want *ErrorResponse", c.name, err)
+				}
+
+				if string(errResp.Body) != tt.errResp {
+					t.Errorf("%s: ErrorResponse.Body = %s, want %s", c.name, errResp.Body, tt.errResp)
+				}
+
+				if !reflect.DeepEqual(errResp.ErrorMessage, tt.want) {
+					t.Errorf("%s: ErrorResponse.ErrorMessage = %v, want %v", c.name, errResp.ErrorMessage, tt.want)
+				}
+
+				var msg *ErrorMessage
+				if errors.As(err, &msg) != (tt.want != nil) {
+					t.Errorf("%s: errors.As(*ErrorMessage) = %v, want %v", c.name, msg, tt.want)
+				}
+			}
+		})
+	}
+}
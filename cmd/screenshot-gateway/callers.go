@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultQuotaWindow is the quota window if it's not set in the config.
+const defaultQuotaWindow = 24 * time.Hour
+
+// callerConfig is the caller entry of the config file.
+type callerConfig struct {
+	// Name identifies the caller in logs
+	Name string `json:"name"`
+
+	// Token is the internal token the caller sends as "Authorization: Bearer <token>"
+	Token string `json:"token"`
+
+	// Quota is the number of captures per window. Zero means unlimited
+	Quota int `json:"quota"`
+
+	// Window is the quota window as a Go duration, e.g. 24h. Default: 24h
+	Window string `json:"window"`
+}
+
+// callersConfig is the config file with the callers allowed to use the gateway.
+type callersConfig struct {
+	Callers []callerConfig `json:"callers"`
+}
+
+// caller is the authenticated caller with its quota usage.
+type caller struct {
+	name   string
+	quota  int
+	window time.Duration
+
+	mu    sync.Mutex
+	start time.Time
+	used  int
+}
+
+// take consumes a capture from the quota. It returns the remaining captures, or false and the time
+// until the window is reset if the quota is exhausted.
+func (c *caller) take(now time.Time) (remaining int, retryAfter time.Duration, ok bool) {
+	if c.quota == 0 {
+		return -1, 0, true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.start) >= c.window {
+		c.start = now
+		c.used = 0
+	}
+
+	if c.used >= c.quota {
+		return 0, c.start.Add(c.window).Sub(now), false
+	}
+
+	c.used++
+
+	return c.quota - c.used, 0, true
+}
+
+// refund returns the capture taken at the specified time to the quota, e.g. if the request has failed
+// without spending an API credit. The capture taken in the previous window is not refunded to the current one.
+func (c *caller) refund(taken time.Time) {
+	if c.quota == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.start.After(taken) {
+		return
+	}
+
+	if c.used > 0 {
+		c.used--
+	}
+}
+
+// callers are the callers indexed by the token hash.
+type callers map[[sha256.Size]byte]*caller
+
+// lookup returns the caller with the token.
+func (cs callers) lookup(token string) (*caller, bool) {
+	if token == "" {
+		return nil, false
+	}
+
+	c, ok := cs[sha256.Sum256([]byte(token))]
+
+	return c, ok
+}
+
+// loadCallers reads the callers config file.
+func loadCallers(path string) (callers, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg callersConfig
+	if err = json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+
+	return newCallers(cfg)
+}
+
+// newCallers validates the config and creates the callers.
+func newCallers(cfg callersConfig) (callers, error) {
+	cs := callers{}
+
+	for i, cc := range cfg.Callers {
+		if cc.Token == "" {
+			return nil, fmt.Errorf("caller %d: token is empty", i+1)
+		}
+		if cc.Quota < 0 {
+			return nil, fmt.Errorf("caller %d: quota is negative", i+1)
+		}
+
+		window := defaultQuotaWindow
+		if cc.Window != "" {
+			var err error
+			if window, err = time.ParseDuration(cc.Window); err != nil || window <= 0 {
+				return nil, fmt.Errorf("caller %d: invalid window %q", i+1, cc.Window)
+			}
+		}
+
+		key := sha256.Sum256([]byte(cc.Token))
+		if _, ok := cs[key]; ok {
+			return nil, fmt.Errorf("caller %d: duplicate token", i+1)
+		}
+
+		cs[key] = &caller{name: cc.Name, quota: cc.Quota, window: window}
+	}
+
+	if len(cs) == 0 {
+		return nil, fmt.Errorf("no callers configured")
+	}
+
+	return cs, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNewCallers tests the config validation.
+func TestNewCallers(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     callersConfig
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			cfg:  callersConfig{Callers: []callerConfig{{Name: "a", Token: "a", Quota: 10, Window: "1h"}, {Token: "b"}}},
+		},
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+		{
+			name:    "no token",
+			cfg:     callersConfig{Callers: []callerConfig{{Name: "a"}}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate token",
+			cfg:     callersConfig{Callers: []callerConfig{{Token: "a"}, {Token: "a"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid window",
+			cfg:     callersConfig{Callers: []callerConfig{{Token: "a", Window: "daily"}}},
+			wantErr: true,
+		},
+		{
+			name:    "negative quota",
+			cfg:     callersConfig{Callers: []callerConfig{{Token: "a", Quota: -1}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newCallers(tt.cfg); (err != nil) != tt.wantErr {
+				t.Errorf("newCallers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestCallerTake tests the quota window.
+func TestCallerTake(t *testing.T) {
+	c := &caller{quota: 2, window: time.Hour}
+	now := time.Date(2022, 11, 3, 12, 0, 0, 0, time.UTC)
+
+	for i, want := range []int{1, 0} {
+		if remaining, _, ok := c.take(now); !ok || remaining != want {
+			t.Fatalf("take() %d = %d, %v, want %d, true", i, remaining, ok, want)
+		}
+	}
+
+	if _, retryAfter, ok := c.take(now.Add(15 * time.Minute)); ok || retryAfter != 45*time.Minute {
+		t.Errorf("take() = %v, %v, want 45m, false", retryAfter, ok)
+	}
+
+	c.refund(now)
+	if _, _, ok := c.take(now.Add(15 * time.Minute)); !ok {
+		t.Error("take() after refund = false, want true")
+	}
+
+	if remaining, _, ok := c.take(now.Add(time.Hour)); !ok || remaining != 1 {
+		t.Errorf("take() in the next window = %d, %v, want 1, true", remaining, ok)
+	}
+
+	// the capture taken in the previous window is not refunded to the current one
+	c.refund(now.Add(15 * time.Minute))
+	if remaining, _, ok := c.take(now.Add(time.Hour)); !ok || remaining != 0 {
+		t.Errorf("take() after the stale refund = %d, %v, want 0, true", remaining, ok)
+	}
+}
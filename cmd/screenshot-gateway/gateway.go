@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	screenshotapi "github.com/whois-api-llc/screenshot-go"
+)
+
+// gateway is the HTTP handler proxying capture requests to the Screenshot API.
+type gateway struct {
+	service screenshotapi.ScreenshotAPIService
+	callers callers
+
+	// maxAge is the Cache-Control max-age of screenshots
+	maxAge time.Duration
+
+	logger *log.Logger
+	now    func() time.Time
+}
+
+// newGateway creates the gateway handler.
+func newGateway(service screenshotapi.ScreenshotAPIService, cs callers, maxAge time.Duration, logger *log.Logger) *gateway {
+	return &gateway{
+		service: service,
+		callers: cs,
+		maxAge:  maxAge,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// routes returns the gateway routes.
+func (g *gateway) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/capture", g.capture)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+
+	return mux
+}
+
+// writeError writes the error in the Screenshot API error format.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(&screenshotapi.ErrorMessage{Code: status, Message: message})
+}
+
+// bearerToken returns the token of the Authorization header.
+func bearerToken(req *http.Request) string {
+	auth := req.Header.Get("Authorization")
+	if len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(auth[len("Bearer "):])
+	}
+
+	return ""
+}
+
+// parseRequest returns the target URL and the options of the capture request. Query parameters
+// mirror the Screenshot API ones, e.g. type, width, fullPage, plus device for OptionDevice.
+// The device settings overridden by explicit parameters are dropped, so the options don't conflict.
+func parseRequest(q url.Values) (string, []screenshotapi.Option, error) {
+	target := q.Get("url")
+	if target == "" {
+		return "", nil, &screenshotapi.ArgError{Name: "url", Message: "can not be empty"}
+	}
+
+	// the API key and the output format are controlled by the gateway
+	for _, name := range []string{"apiKey", "imageOutputFormat", "errorsOutputFormat"} {
+		if _, ok := q[name]; ok {
+			return "", nil, &screenshotapi.ArgError{Name: name, Message: "is not allowed"}
+		}
+	}
+
+	capture, err := screenshotapi.ParseCaptureOptions(q)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var opts []screenshotapi.Option
+
+	// the device goes first, so explicit options override its settings
+	if name := q.Get("device"); name != "" {
+		opts = append(opts, screenshotapi.OptionDevice(name))
+	}
+
+	opts = append(opts, capture.Options()...)
+
+	if err = screenshotapi.ValidateOptions(opts...); err != nil {
+		return "", nil, err
+	}
+
+	return target, opts, nil
+}
+
+// upstreamStatus returns the gateway status code for the Screenshot API error. Errors caused
+// by the gateway configuration, such as the invalid API key, are reported as bad gateway.
+func upstreamStatus(err error) int {
+	switch {
+	case errors.Is(err, screenshotapi.ErrBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, screenshotapi.ErrHostnameChanged):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, screenshotapi.ErrPageTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, screenshotapi.ErrRateLimited):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+
+	return http.StatusBadGateway
+}
+
+// capture handles the capture request.
+func (g *gateway) capture(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	c, ok := g.callers.lookup(bearerToken(req))
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="screenshot-gateway"`)
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	target, opts, err := parseRequest(req.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	taken := g.now()
+
+	remaining, retryAfter, ok := c.take(taken)
+	if c.quota != 0 {
+		w.Header().Set("X-Quota-Limit", strconv.Itoa(c.quota))
+		w.Header().Set("X-Quota-Remaining", strconv.Itoa(remaining))
+	}
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, "quota exceeded")
+		return
+	}
+
+	opts = append(opts,
+		screenshotapi.OptionErrorsOutputFormat("JSON"),
+		screenshotapi.OptionImageOutputFormat("image"),
+		screenshotapi.OptionDecodeDataURI(true),
+	)
+
+	start := time.Now()
+
+	resp, err := g.service.GetRaw(req.Context(), target, opts...)
+	if err == nil && screenshotapi.DetectFormat(resp.Body) == screenshotapi.FormatUnknown {
+		err = screenshotapi.ErrInvalidBody
+	}
+	if err != nil {
+		if !creditSpent(resp) {
+			c.refund(taken)
+		}
+		g.logger.Printf("caller %s: %s: %v", c.name, target, err)
+
+		var msg *screenshotapi.ErrorMessage
+		if errors.As(err, &msg) && msg.Message != "" {
+			writeError(w, upstreamStatus(err), msg.Message)
+		} else {
+			writeError(w, upstreamStatus(err), "capture failed")
+		}
+		return
+	}
+
+	sum := sha256.Sum256(resp.Body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	contentType := resp.MediaType
+	if contentType == "" {
+		contentType = resp.Header.Get("Content-Type")
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(resp.Body)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(g.maxAge.Seconds())))
+
+	g.logger.Printf("caller %s: %s: %d bytes in %s", c.name, target, len(resp.Body), time.Since(start))
+
+	if match := req.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(resp.Body)))
+
+	if req.Method == http.MethodHead {
+		return
+	}
+
+	_, _ = w.Write(resp.Body)
+}
+
+// creditSpent reports whether the API has charged for the response. Only successful captures are charged,
+// so a 2xx response with an invalid body still counts.
+func creditSpent(resp *screenshotapi.Response) bool {
+	return resp != nil && resp.Response != nil && resp.StatusCode >= 200 && resp.StatusCode <= 299
+}
+
+// etagMatches reports whether the If-None-Match header matches the ETag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	screenshotapi "github.com/whois-api-llc/screenshot-go"
+)
+
+const testAPIKey = "at_LoremIpsumDolorSitAmetConsect"
+
+// pngImage returns the PNG image of the specified size.
+func pngImage(width, height int) []byte {
+	var b bytes.Buffer
+
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		panic(err)
+	}
+
+	return b.Bytes()
+}
+
+// newTestGateway returns the gateway in front of the Screenshot API stand-in, the stand-in
+// records the last query and counts calls.
+func newTestGateway(t *testing.T, cfg callersConfig, last *url.Values, calls *int32) *httptest.Server {
+	t.Helper()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(calls, 1)
+
+		q := req.URL.Query()
+		*last = q
+
+		switch q.Get("url") {
+		case "nocredits.com":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"code":403,"messages":"Access restricted. Check credits balance."}`))
+		case "redirect.com":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"code":422,"messages":"Hostname changed."}`))
+		case "invalid.com":
+			_, _ = w.Write([]byte("not an image"))
+		default:
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(pngImage(10, 10))
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	cache, err := screenshotapi.NewCache(screenshotapi.CacheParams{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiURL, _ := url.Parse(upstream.URL)
+	client := screenshotapi.NewClient(testAPIKey, screenshotapi.ClientParams{
+		HTTPClient:           upstream.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+		Cache:                cache,
+	})
+
+	cs, err := newCallers(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := newGateway(client, cs, time.Hour, log.New(io.Discard, "", 0))
+
+	server := httptest.NewServer(g.routes())
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// get makes the capture request.
+func get(t *testing.T, server *httptest.Server, token, query string, header http.Header) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/capture?"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp, body
+}
+
+// TestGateway tests capture requests.
+func TestGateway(t *testing.T) {
+	var (
+		last  url.Values
+		calls int32
+	)
+
+	server := newTestGateway(t, callersConfig{Callers: []callerConfig{
+		{Name: "app", Token: "secret"},
+	}}, &last, &calls)
+
+	tests := []struct {
+		name        string
+		token       string
+		query       string
+		wantStatus  int
+		wantBody    string
+		wantUpQuery url.Values
+	}{
+		{
+			name:       "no token",
+			query:      "url=example.com",
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   `"messages":"invalid token"`,
+		},
+		{
+			name:       "wrong token",
+			token:      "wrong",
+			query:      "url=example.com",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "no url",
+			token:      "secret",
+			query:      "type=png",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid options",
+			token:      "secret",
+			query:      "url=example.com&type=gif&width=1",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "imageType",
+		},
+		{
+			name:       "api key",
+			token:      "secret",
+			query:      "url=example.com&apiKey=at_other",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "apiKey",
+		},
+		{
+			name:       "device",
+			token:      "secret",
+			query:      "url=example.com&device=iphone-14&width=500&landscape=true&type=png",
+			wantStatus: http.StatusOK,
+			wantUpQuery: url.Values{
+				"apiKey":    {testAPIKey},
+				"width":     {"500"},
+				"height":    {"844"},
+				"landscape": {"true"},
+				"mobile":    {"true"},
+			},
+		},
+		{
+			name:       "insufficient credits",
+			token:      "secret",
+			query:      "url=nocredits.com",
+			wantStatus: http.StatusBadGateway,
+			wantBody:   "Access restricted",
+		},
+		{
+			name:       "hostname changed",
+			token:      "secret",
+			query:      "url=redirect.com",
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   "Hostname changed.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := get(t, server, tt.token, tt.query, nil)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body = %s, want to contain %s", body, tt.wantBody)
+			}
+			if strings.Contains(string(body), testAPIKey) {
+				t.Error("the API key is leaked")
+			}
+			for name := range tt.wantUpQuery {
+				if got, want := last.Get(name), tt.wantUpQuery.Get(name); got != want {
+					t.Errorf("upstream %s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestGatewayCacheAndETag tests the response headers, the cache and conditional requests.
+func TestGatewayCacheAndETag(t *testing.T) {
+	var (
+		last  url.Values
+		calls int32
+	)
+
+	server := newTestGateway(t, callersConfig{Callers: []callerConfig{
+		{Name: "app", Token: "secret"},
+	}}, &last, &calls)
+
+	resp, body := get(t, server, "secret", "url=example.com&type=png", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body: %s", resp.StatusCode, body)
+	}
+	if !bytes.Equal(body, pngImage(10, 10)) {
+		t.Error("body mismatch")
+	}
+	if got := resp.Header.Get("Content-Type"); got != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", got)
+	}
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag is empty")
+	}
+
+	resp, body = get(t, server, "secret", "url=EXAMPLE.com&type=png", http.Header{"If-None-Match": {etag}})
+	if resp.StatusCode != http.StatusNotModified || len(body) != 0 {
+		t.Errorf("status = %d, body length = %d, want 304 and empty body", resp.StatusCode, len(body))
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("upstream calls = %d, want 1", got)
+	}
+}
+
+// TestGatewayQuota tests per-caller quotas.
+func TestGatewayQuota(t *testing.T) {
+	var (
+		last  url.Values
+		calls int32
+	)
+
+	server := newTestGateway(t, callersConfig{Callers: []callerConfig{
+		{Name: "limited", Token: "limited", Quota: 2, Window: "1h"},
+		{Name: "unlimited", Token: "unlimited"},
+	}}, &last, &calls)
+
+	// failed captures don't spend the quota
+	if resp, _ := get(t, server, "limited", "url=nocredits.com", nil); resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusBadGateway)
+	}
+
+	// the capture with the invalid body is charged by the API, so it spends the quota too
+	resp, _ := get(t, server, "limited", "url=invalid.com", nil)
+	if resp.StatusCode != http.StatusBadGateway || resp.Header.Get("X-Quota-Remaining") != "1" {
+		t.Fatalf("status = %d, X-Quota-Remaining = %s, want %d, 1",
+			resp.StatusCode, resp.Header.Get("X-Quota-Remaining"), http.StatusBadGateway)
+	}
+
+	resp, body := get(t, server, "limited", "url=example.com", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body: %s", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("X-Quota-Remaining"); got != "0" {
+		t.Errorf("X-Quota-Remaining = %s, want 0", got)
+	}
+
+	resp, body = get(t, server, "limited", "url=example.com", nil)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d, body: %s", resp.StatusCode, http.StatusTooManyRequests, body)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Error("Retry-After is empty")
+	}
+
+	if resp, _ = get(t, server, "unlimited", "url=example.com", nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
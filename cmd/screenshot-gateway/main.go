@@ -0,0 +1,158 @@
+// Command screenshot-gateway is the self-hosted HTTP gateway to the Screenshot API. Internal apps
+// authenticate with their own tokens instead of the API key, and are limited by per-caller quotas.
+//
+// Usage:
+//
+//	screenshot-gateway --callers callers.json [flags]
+//
+// The API key is read from the --api-key flag or the SCREENSHOT_API_KEY environment variable.
+// The callers file lists the tokens and quotas:
+//
+//	{"callers": [{"name": "billing", "token": "...", "quota": 1000, "window": "24h"}]}
+//
+// Capture requests mirror the Screenshot API query parameters, plus device for the device presets:
+//
+//	GET /capture?url=example.com&type=png&width=1280&height=720
+//	Authorization: Bearer <token>
+//
+// The response is the screenshot with the Content-Type and ETag headers, or the JSON error
+// in the Screenshot API format. Successful responses are cached on disk.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	screenshotapi "github.com/whois-api-llc/screenshot-go"
+)
+
+// apiKeyEnv is the environment variable with the API key.
+const apiKeyEnv = "SCREENSHOT_API_KEY"
+
+// shutdownTimeout is the time given to in-flight requests on shutdown.
+const shutdownTimeout = 30 * time.Second
+
+// defaultCacheDir returns the default cache directory, or empty string if it's unknown.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "screenshot-gateway")
+}
+
+func main() {
+	var (
+		addr          string
+		apiKey        string
+		callersPath   string
+		baseURL       string
+		cacheDir      string
+		cacheTTL      time.Duration
+		cacheMaxSize  int64
+		maxConcurrent int
+		rate          float64
+	)
+
+	flag.StringVar(&addr, "addr", ":8080", "listen address")
+	flag.StringVar(&apiKey, "api-key", "", "API key, overrides "+apiKeyEnv)
+	flag.StringVar(&callersPath, "callers", "", "JSON file with caller tokens and quotas")
+	flag.StringVar(&baseURL, "base-url", "", "Screenshot API base URL")
+	flag.StringVar(&cacheDir, "cache-dir", defaultCacheDir(), "response cache directory, empty to disable the cache")
+	flag.DurationVar(&cacheTTL, "cache-ttl", time.Hour, "time cached screenshots are served for")
+	flag.Int64Var(&cacheMaxSize, "cache-max-size", 1<<30, "maximum cache size in bytes")
+	flag.IntVar(&maxConcurrent, "max-concurrent", 0, "maximum concurrent API requests, 0 for unlimited")
+	flag.Float64Var(&rate, "rate", 0, "maximum API requests per second, 0 for unlimited")
+	flag.Parse()
+
+	logger := log.New(os.Stderr, "screenshot-gateway: ", log.LstdFlags)
+
+	if apiKey == "" {
+		apiKey = os.Getenv(apiKeyEnv)
+	}
+	if apiKey == "" {
+		logger.Fatalf("the API key is not set: use --api-key or %s", apiKeyEnv)
+	}
+
+	if callersPath == "" {
+		logger.Fatal("the callers file is not set: use --callers")
+	}
+
+	cs, err := loadCallers(callersPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	params := screenshotapi.ClientParams{
+		RetryPolicy:           screenshotapi.DefaultRetryPolicy(),
+		RateLimit:             rate,
+		MaxConcurrentRequests: maxConcurrent,
+		ValidateBody:          true,
+	}
+
+	if baseURL != "" {
+		if params.ScreenshotAPIBaseURL, err = url.Parse(baseURL); err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	if cacheDir != "" {
+		params.Cache, err = screenshotapi.NewCache(screenshotapi.CacheParams{
+			Dir:     cacheDir,
+			TTL:     cacheTTL,
+			MaxSize: cacheMaxSize,
+		})
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	client := screenshotapi.NewClient(apiKey, params)
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           newGateway(client, cs, cacheTTL, logger).routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// ListenAndServe returns as soon as Shutdown is called, done is closed when the requests are finished
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Print(err)
+		}
+	}()
+
+	logger.Printf("listening on %s", addr)
+
+	if err = server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Fatal(err)
+	}
+
+	<-done
+
+	if params.Cache != nil {
+		stats := params.Cache.Stats()
+		logger.Printf("cache: %d hits, %d misses, %d entries", stats.Hits, stats.Misses, stats.Entries)
+	}
+}
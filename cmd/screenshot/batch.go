@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	screenshotapi "github.com/whois-api-llc/screenshot-go"
+)
+
+// Input formats.
+const (
+	formatTXT   = "txt"
+	formatCSV   = "csv"
+	formatJSONL = "jsonl"
+)
+
+// defaultTemplate is the default output path template of batch mode. The path and the hash of the URL
+// and options make it unique per capture, so pages of one host don't overwrite each other.
+const defaultTemplate = "{{.Host}}/{{.Path}}-{{.Hash}}.{{.Ext}}"
+
+// inputRow is the line of the JSONL input. CSV columns have the same names, options are
+// the query parameter names, e.g. type, width, fullPage, cookies.
+type inputRow struct {
+	ID       string                        `json:"id"`
+	URL      string                        `json:"url"`
+	Filename string                        `json:"filename"`
+	Device   string                        `json:"device"`
+	Options  *screenshotapi.CaptureOptions `json:"options"`
+}
+
+// batchFlags are the flags of batch mode.
+type batchFlags struct {
+	input       string
+	inputFormat string
+	concurrency int
+	rate        float64
+	outputDir   string
+	template    string
+	report      string
+}
+
+// register defines the batch flags in the flag set.
+func (b *batchFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&b.input, "input", "", "batch mode: file with URLs (.txt, .csv or .jsonl), - for stdin")
+	fs.StringVar(&b.inputFormat, "input-format", "", "input format: txt | csv | jsonl, by default the file extension")
+	fs.IntVar(&b.concurrency, "concurrency", screenshotapi.DefaultBatchWorkers, "batch mode: number of concurrent captures")
+	fs.Float64Var(&b.rate, "rate", 0, "batch mode: maximum captures per second, 0 for unlimited")
+	fs.StringVar(&b.outputDir, "output-dir", ".", "batch mode: output directory")
+	fs.StringVar(&b.template, "template", defaultTemplate, "batch mode: output path template inside the output directory")
+	fs.StringVar(&b.report, "report", "-", "batch mode: JSON summary report file, - for stdout")
+}
+
+// format returns the input format.
+func (b *batchFlags) format() (string, error) {
+	format := b.inputFormat
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(b.input)), ".")
+	}
+
+	switch format {
+	case formatTXT, formatCSV, formatJSONL:
+		return format, nil
+	case "":
+		return formatTXT, nil
+	}
+
+	return "", fmt.Errorf("unknown input format %q: use txt, csv or jsonl", format)
+}
+
+// readJobs reads the jobs from the input. Options of the rows are applied after opts.
+func readJobs(r io.Reader, format string, opts []screenshotapi.Option) ([]screenshotapi.Job, error) {
+	var (
+		rows []inputRow
+		err  error
+	)
+
+	switch format {
+	case formatCSV:
+		rows, err = readCSV(r)
+	case formatJSONL:
+		rows, err = readJSONL(r)
+	default:
+		rows, err = readTXT(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]screenshotapi.Job, 0, len(rows))
+
+	for i, row := range rows {
+		if row.URL == "" {
+			return nil, fmt.Errorf("row %d: url is empty", i+1)
+		}
+
+		job := screenshotapi.Job{
+			ID:       row.ID,
+			URL:      row.URL,
+			Filename: row.Filename,
+			Options:  append([]screenshotapi.Option{}, opts...),
+		}
+		if job.ID == "" {
+			job.ID = strconv.Itoa(i + 1)
+		}
+		if row.Device != "" {
+			job.Options = append(job.Options, screenshotapi.OptionDevice(row.Device))
+		}
+		if row.Options != nil {
+			job.Options = append(job.Options, row.Options.Options()...)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// readTXT reads URLs one per line. Empty lines and lines starting with # are skipped.
+func readTXT(r io.Reader) ([]inputRow, error) {
+	var rows []inputRow
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rows = append(rows, inputRow{URL: line})
+	}
+
+	return rows, scanner.Err()
+}
+
+// optionColumns are the CSV columns of options: the query parameters, which are also the JSON names
+// of CaptureOptions fields.
+var optionColumns = func() map[string]bool {
+	columns := map[string]bool{}
+
+	t := reflect.TypeOf(screenshotapi.CaptureOptions{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		columns[name] = true
+	}
+
+	return columns
+}()
+
+// readCSV reads rows with the header. The url column is mandatory, the id, filename and device columns
+// are optional, other columns are options named after the query parameters. Empty cells are not set.
+// Unknown columns are an error, so misspelt options are not silently ignored.
+func readCSV(r io.Reader) ([]inputRow, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+
+		switch name := header[i]; name {
+		case "id", "url", "filename", "device":
+		default:
+			if !optionColumns[name] {
+				return nil, fmt.Errorf("unknown column %q", name)
+			}
+		}
+	}
+
+	rows := make([]inputRow, 0, len(records)-1)
+
+	for line, record := range records[1:] {
+		row := inputRow{}
+		v := url.Values{}
+
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+
+			switch header[i] {
+			case "id":
+				row.ID = value
+			case "url":
+				row.URL = value
+			case "filename":
+				row.Filename = value
+			case "device":
+				row.Device = value
+			default:
+				v.Set(header[i], value)
+			}
+		}
+
+		if len(v) != 0 {
+			if row.Options, err = screenshotapi.ParseCaptureOptions(v); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line+2, err)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readJSONL reads one JSON object per line. Empty lines are skipped.
+func readJSONL(r io.Reader) ([]inputRow, error) {
+	var rows []inputRow
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	for line := 1; scanner.Scan(); line++ {
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		var row inputRow
+		if err := json.Unmarshal([]byte(raw), &row); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, scanner.Err()
+}
+
+// report is the JSON summary report of batch mode.
+type report struct {
+	Total         int            `json:"total"`
+	Succeeded     int            `json:"succeeded"`
+	Failed        int            `json:"failed"`
+	FailedByClass map[string]int `json:"failedByClass"`
+	CreditsUsed   int            `json:"creditsUsed"`
+	ElapsedMs     int64          `json:"elapsedMs"`
+	Canceled      bool           `json:"canceled,omitempty"`
+	Jobs          []jobReport    `json:"jobs"`
+}
+
+// jobReport is the outcome of the job in the report.
+type jobReport struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	Filename  string `json:"filename,omitempty"`
+	SHA256    string `json:"sha256,omitempty"`
+	Cached    bool   `json:"cached,omitempty"`
+	Shared    bool   `json:"shared,omitempty"`
+	Error     string `json:"error,omitempty"`
+	ElapsedMs int64  `json:"elapsedMs"`
+}
+
+// runBatch captures the jobs from the input and writes the report. It returns the exit code:
+// exitOK if all jobs are succeeded, exitError otherwise.
+func runBatch(
+	ctx context.Context,
+	client *screenshotapi.Client,
+	b *batchFlags,
+	opts []screenshotapi.Option,
+	stdin io.Reader,
+	stdout, stderr io.Writer,
+) int {
+	format, err := b.format()
+	if err != nil {
+		printError(stderr, err)
+		return exitUsage
+	}
+
+	tmpl, err := screenshotapi.ParsePathTemplate(b.template)
+	if err != nil {
+		printError(stderr, err)
+		return exitUsage
+	}
+
+	input := stdin
+	if b.input != "-" {
+		f, err := os.Open(b.input)
+		if err != nil {
+			printError(stderr, err)
+			return exitUsage
+		}
+		defer f.Close()
+		input = f
+	}
+
+	jobs, err := readJobs(input, format, opts)
+	if err != nil {
+		printError(stderr, fmt.Errorf("cannot read input: %w", err))
+		return exitUsage
+	}
+
+	for i := range jobs {
+		if jobs[i].Filename != "" && !filepath.IsAbs(jobs[i].Filename) {
+			jobs[i].Filename = filepath.Join(b.outputDir, jobs[i].Filename)
+		}
+	}
+
+	rep := report{Jobs: make([]jobReport, 0, len(jobs))}
+
+	batch := screenshotapi.NewBatch(client, screenshotapi.BatchParams{
+		Workers:      b.concurrency,
+		RateLimit:    b.rate,
+		FileParams:   screenshotapi.FileParams{CreateDirs: true},
+		PathTemplate: tmpl,
+		OutputDir:    b.outputDir,
+		OnProgress: func(p screenshotapi.Progress) {
+			res := p.Last
+
+			job := jobReport{
+				ID:        res.Job.ID,
+				URL:       res.Job.URL,
+				Filename:  res.Filename,
+				ElapsedMs: res.Elapsed.Milliseconds(),
+			}
+			if res.Result != nil {
+				job.SHA256 = res.Result.SHA256
+				job.Cached = res.Result.Cached
+				job.Shared = res.Result.Shared
+			}
+
+			status := "ok"
+			if res.Err != nil {
+				job.Error = res.Err.Error()
+				status = "failed: " + res.Err.Error()
+			}
+			rep.Jobs = append(rep.Jobs, job)
+
+			fmt.Fprintf(stderr, "[%d/%d] %s %s\n", p.Done, p.Total, res.Job.URL, status)
+		},
+	})
+
+	summary, err := batch.Run(ctx, jobs)
+
+	rep.Total = summary.Total
+	rep.Succeeded = summary.Succeeded
+	rep.Failed = summary.Failed
+	rep.FailedByClass = summary.FailedByClass
+	rep.CreditsUsed = summary.CreditsUsed
+	rep.ElapsedMs = summary.Elapsed.Milliseconds()
+	rep.Canceled = errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+
+	if werr := writeReport(b.report, &rep, stdout); werr != nil {
+		printError(stderr, fmt.Errorf("cannot write report: %w", werr))
+		return exitError
+	}
+
+	if err != nil || rep.Failed != 0 || rep.Total != len(jobs) {
+		return exitError
+	}
+
+	return exitOK
+}
+
+// writeReport writes the indented JSON report to the file or stdout.
+func writeReport(path string, rep *report, stdout io.Writer) error {
+	raw, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return err
+	}
+	raw = append(raw, '\n')
+
+	if path == "-" {
+		_, err = stdout.Write(raw)
+		return err
+	}
+
+	_, err = screenshotapi.WriteFile(path, bytes.NewReader(raw), screenshotapi.FileParams{})
+
+	return err
+}
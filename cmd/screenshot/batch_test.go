@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	screenshotapi "github.com/whois-api-llc/screenshot-go"
+)
+
+// TestReadJobs tests reading the jobs in every input format.
+func TestReadJobs(t *testing.T) {
+	global := []screenshotapi.Option{screenshotapi.OptionType("png"), screenshotapi.OptionWidth(1024)}
+
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		want    []screenshotapi.Job
+		wantQ   []url.Values
+		wantErr bool
+	}{
+		{
+			name:   "txt",
+			format: formatTXT,
+			input:  "# domains\na.com\n\n  b.com  \n",
+			want:   []screenshotapi.Job{{ID: "1", URL: "a.com"}, {ID: "2", URL: "b.com"}},
+			wantQ: []url.Values{
+				{"type": {"png"}, "width": {"1024"}},
+				{"type": {"png"}, "width": {"1024"}},
+			},
+		},
+		{
+			name:   "csv",
+			format: formatCSV,
+			input:  "id,url,filename,device,type,width,fullPage,cookies\nhome,a.com,a.jpg,,jpg,,true,a=1\n,b.com,,ipad,,800,,\n",
+			want: []screenshotapi.Job{
+				{ID: "home", URL: "a.com", Filename: "a.jpg"},
+				{ID: "2", URL: "b.com"},
+			},
+			wantQ: []url.Values{
+				{"type": {"jpg"}, "width": {"1024"}, "fullPage": {"true"}, "cookies": {"a=1"}},
+				{"type": {"png"}, "width": {"800"}, "height": {"1080"}, "mobile": {"true"}},
+			},
+		},
+		{
+			name:   "jsonl",
+			format: formatJSONL,
+			input:  `{"url":"a.com","options":{"width":640,"scroll":true}}` + "\n\n" + `{"id":"x","url":"b.com","device":"iphone-14"}` + "\n",
+			want:   []screenshotapi.Job{{ID: "1", URL: "a.com"}, {ID: "x", URL: "b.com"}},
+			wantQ: []url.Values{
+				{"type": {"png"}, "width": {"640"}, "scroll": {"true"}},
+				{"type": {"png"}, "width": {"390"}, "height": {"844"}},
+			},
+		},
+		{
+			name:    "csv invalid option",
+			format:  formatCSV,
+			input:   "url,width\na.com,wide\n",
+			wantErr: true,
+		},
+		{
+			name:    "csv unknown column",
+			format:  formatCSV,
+			input:   "url,widht\na.com,800\n",
+			wantErr: true,
+		},
+		{
+			name:    "jsonl without url",
+			format:  formatJSONL,
+			input:   `{"id":"1"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := readJobs(strings.NewReader(tt.input), tt.format, global)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readJobs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(jobs) != len(tt.want) {
+				t.Fatalf("readJobs() = %d jobs, want %d", len(jobs), len(tt.want))
+			}
+
+			for i, job := range jobs {
+				if job.ID != tt.want[i].ID || job.URL != tt.want[i].URL || job.Filename != tt.want[i].Filename {
+					t.Errorf("job %d = %+v, want %+v", i, job, tt.want[i])
+				}
+
+				q := url.Values{}
+				for _, opt := range job.Options {
+					if err = opt(q); err != nil {
+						t.Fatal(err)
+					}
+				}
+				for name := range tt.wantQ[i] {
+					if got, want := q.Get(name), tt.wantQ[i].Get(name); got != want {
+						t.Errorf("job %d: %s = %q, want %q", i, name, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestRunBatch tests batch mode.
+func TestRunBatch(t *testing.T) {
+	var last url.Values
+
+	server := newServer(&last)
+	defer server.Close()
+
+	// the output directory is not a part of the template
+	dir := filepath.Join(t.TempDir(), "{{shots}}")
+
+	var stdout, stderr bytes.Buffer
+
+	args := []string{"--base-url", server.URL, "--api-key", testAPIKey, "--type", "png",
+		"--input", "-", "--output-dir", dir, "--template", "{{.ID}}-{{.Host}}.{{.Ext}}", "--concurrency", "2"}
+
+	stdin := strings.NewReader("a.com\nnocredits.com\nb.com\n")
+
+	if code := run(context.Background(), args, stdin, &stdout, &stderr, os.Getenv); code != exitError {
+		t.Fatalf("run() = %d, want %d, stderr: %s", code, exitError, stderr.String())
+	}
+
+	var rep report
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+
+	if rep.Total != 3 || rep.Succeeded != 2 || rep.Failed != 1 || rep.CreditsUsed != 2 ||
+		rep.FailedByClass[screenshotapi.ErrInsufficientCredits.Error()] != 1 || len(rep.Jobs) != 3 {
+		t.Errorf("report = %+v", rep)
+	}
+
+	for _, name := range []string{"1-a.com.png", "3-b.com.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// the report file and the exit code of the successful batch
+	input := filepath.Join(dir, "urls.csv")
+	if err := os.WriteFile(input, []byte("url,filename\nc.com,sub/c.png\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	reportPath := filepath.Join(dir, "report.json")
+	args = []string{"--base-url", server.URL, "--api-key", testAPIKey,
+		"--input", input, "--output-dir", dir, "--report", reportPath}
+
+	stdout.Reset()
+	if code := run(context.Background(), args, nil, &stdout, &stderr, os.Getenv); code != exitOK {
+		t.Fatalf("run() = %d, want %d, stderr: %s", code, exitOK, stderr.String())
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "sub", "c.png")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(reportPath); err != nil {
+		t.Error(err)
+	}
+
+	// the default template keeps the pages of one host apart
+	pagesDir := t.TempDir()
+	args = []string{"--base-url", server.URL, "--api-key", testAPIKey, "--type", "png",
+		"--input", "-", "--output-dir", pagesDir, "--concurrency", "2"}
+
+	stdin = strings.NewReader("example.com/a\nexample.com/b\nexample.com\n")
+	if code := run(context.Background(), args, stdin, &stdout, &stderr, os.Getenv); code != exitOK {
+		t.Fatalf("run() = %d, want %d, stderr: %s", code, exitOK, stderr.String())
+	}
+
+	files, err := filepath.Glob(filepath.Join(pagesDir, "example.com", "*.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Errorf("files = %v, want 3 files", files)
+	}
+
+	// the URL argument is not allowed in batch mode
+	if code := run(context.Background(), append(args, "d.com"), nil, &stdout, &stderr, os.Getenv); code != exitUsage {
+		t.Errorf("run() = %d, want %d", code, exitUsage)
+	}
+}
@@ -0,0 +1,255 @@
+// Command screenshot captures web page screenshots with the Screenshot API.
+//
+// Usage:
+//
+//	screenshot [flags] URL
+//	screenshot [flags] --input urls.txt|urls.csv|urls.jsonl
+//
+// In batch mode URLs are read from the input file, one per line, or from CSV and JSONL rows
+// with the url, id, filename and device fields and per-row options. Screenshots are written to
+// the output directory according to the path template, and the JSON summary report is printed
+// at the end.
+//
+// The API key is read from the --api-key flag, the SCREENSHOT_API_KEY environment variable
+// or the apiKey field of the JSON config file, in this order. Every library option is available
+// as a flag, run screenshot --help for the list.
+//
+// Exit codes:
+//
+//	0   success
+//	1   other errors
+//	2   invalid flags or options
+//	3   bad request
+//	4   invalid API key
+//	5   insufficient credits
+//	6   target page timeout
+//	7   hostname changed
+//	8   rate limited
+//	9   server error
+//	10  invalid response body
+//
+// In batch mode the exit code is 0 if all URLs are captured and 1 otherwise.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	screenshotapi "github.com/whois-api-llc/screenshot-go"
+)
+
+// apiKeyEnv is the environment variable with the API key.
+const apiKeyEnv = "SCREENSHOT_API_KEY"
+
+// Exit codes.
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitBadRequest
+	exitInvalidAPIKey
+	exitInsufficientCredits
+	exitPageTimeout
+	exitHostnameChanged
+	exitRateLimited
+	exitServerError
+	exitInvalidBody
+)
+
+// exitCodes maps error classes to exit codes.
+var exitCodes = []struct {
+	class error
+	code  int
+}{
+	{screenshotapi.ErrBadRequest, exitBadRequest},
+	{screenshotapi.ErrInvalidAPIKey, exitInvalidAPIKey},
+	{screenshotapi.ErrInsufficientCredits, exitInsufficientCredits},
+	{screenshotapi.ErrPageTimeout, exitPageTimeout},
+	{screenshotapi.ErrHostnameChanged, exitHostnameChanged},
+	{screenshotapi.ErrRateLimited, exitRateLimited},
+	{screenshotapi.ErrServerError, exitServerError},
+	{screenshotapi.ErrInvalidBody, exitInvalidBody},
+}
+
+// exitCode returns the exit code for the error.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	for _, c := range exitCodes {
+		if errors.Is(err, c.class) {
+			return c.code
+		}
+	}
+
+	return exitError
+}
+
+// config is the JSON config file.
+type config struct {
+	APIKey string `json:"apiKey"`
+}
+
+// defaultConfigPath returns the default config file path, or empty string if it's unknown.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "screenshot", "config.json")
+}
+
+// apiKey returns the API key from the flag, the environment or the config file.
+// A missing config file is not an error unless it's set explicitly.
+func apiKey(flagKey, configPath string, configSet bool, getenv func(string) string) (string, error) {
+	if flagKey != "" {
+		return flagKey, nil
+	}
+
+	if key := getenv(apiKeyEnv); key != "" {
+		return key, nil
+	}
+
+	if configPath != "" {
+		raw, err := os.ReadFile(configPath)
+		if err != nil && (configSet || !os.IsNotExist(err)) {
+			return "", err
+		}
+		if err == nil {
+			var c config
+			if err = json.Unmarshal(raw, &c); err != nil {
+				return "", fmt.Errorf("cannot parse config %s: %w", configPath, err)
+			}
+			if c.APIKey != "" {
+				return c.APIKey, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("the API key is not set: use --api-key, %s or the config file", apiKeyEnv)
+}
+
+// printError prints the error, the Screenshot API error message is printed as is.
+func printError(w io.Writer, err error) {
+	var msg *screenshotapi.ErrorMessage
+	if errors.As(err, &msg) {
+		fmt.Fprintf(w, "screenshot: API error %d: %s\n", msg.Code, msg.Message)
+		return
+	}
+
+	fmt.Fprintf(w, "screenshot: %v\n", err)
+}
+
+// run runs the command and returns the exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) int {
+	fs := flag.NewFlagSet("screenshot", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: screenshot [flags] URL\n       screenshot [flags] --input FILE")
+		fs.PrintDefaults()
+	}
+
+	var (
+		flagKey    string
+		configPath string
+		output     string
+		baseURL    string
+		options    optionFlags
+		batch      batchFlags
+	)
+
+	fs.StringVar(&flagKey, "api-key", "", "API key, overrides "+apiKeyEnv+" and the config file")
+	fs.StringVar(&configPath, "config", defaultConfigPath(), "JSON config file with the apiKey field")
+	fs.StringVar(&output, "o", "-", "output file or path template, - for stdout")
+	fs.StringVar(&output, "output", "-", "output file or path template, - for stdout")
+	fs.StringVar(&baseURL, "base-url", "", "Screenshot API base URL")
+	options.register(fs)
+	batch.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	batchMode := batch.input != ""
+	if (batchMode && (fs.NArg() != 0 || output != "-")) || (!batchMode && fs.NArg() != 1) {
+		fs.Usage()
+		return exitUsage
+	}
+
+	opts := options.Options(fs)
+	if err := screenshotapi.ValidateOptions(opts...); err != nil {
+		printError(stderr, err)
+		return exitUsage
+	}
+
+	configSet := false
+	fs.Visit(func(f *flag.Flag) {
+		configSet = configSet || f.Name == "config"
+	})
+
+	key, err := apiKey(flagKey, configPath, configSet, getenv)
+	if err != nil {
+		printError(stderr, err)
+		return exitUsage
+	}
+
+	fileParams := screenshotapi.FileParams{CreateDirs: true}
+
+	params := screenshotapi.ClientParams{
+		FileParams: fileParams,
+	}
+	if baseURL != "" {
+		if params.ScreenshotAPIBaseURL, err = url.Parse(baseURL); err != nil {
+			printError(stderr, err)
+			return exitUsage
+		}
+	}
+
+	client := screenshotapi.NewClient(key, params)
+
+	if batchMode {
+		return runBatch(ctx, client, &batch, opts, stdin, stdout, stderr)
+	}
+
+	switch {
+	case output == "-":
+		err = client.GetTo(ctx, fs.Arg(0), stdout, opts...)
+	case strings.Contains(output, "{{"):
+		var tmpl *screenshotapi.PathTemplate
+		if tmpl, err = screenshotapi.ParsePathTemplate(filepath.ToSlash(output)); err != nil {
+			printError(stderr, err)
+			return exitUsage
+		}
+		_, err = client.GetTemplate(ctx, fs.Arg(0), tmpl, fileParams, opts...)
+	default:
+		err = client.Get(ctx, fs.Arg(0), output, opts...)
+	}
+	if err != nil {
+		printError(stderr, err)
+		return exitCode(err)
+	}
+
+	return exitOK
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv)
+	stop()
+
+	os.Exit(code)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testAPIKey = "at_LoremIpsumDolorSitAmetConsect"
+
+// pngImage returns the PNG image of the specified size.
+func pngImage(width, height int) []byte {
+	var b bytes.Buffer
+
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		panic(err)
+	}
+
+	return b.Bytes()
+}
+
+// newServer returns the Screenshot API stand-in which records the last query.
+func newServer(last *url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		*last = q
+
+		switch {
+		case q.Get("apiKey") != testAPIKey:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":401,"messages":"Invalid API key."}`))
+		case q.Get("url") == "nocredits.com":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"code":403,"messages":"Access restricted. Check credits balance."}`))
+		case q.Get("url") == "redirect.com":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"code":422,"messages":"Hostname changed."}`))
+		default:
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(pngImage(10, 10))
+		}
+	}))
+}
+
+// TestRun tests the command.
+func TestRun(t *testing.T) {
+	var last url.Values
+
+	server := newServer(&last)
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"apiKey":"`+testAPIKey+`"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	noEnv := func(string) string { return "" }
+
+	tests := []struct {
+		name       string
+		args       []string
+		getenv     func(string) string
+		wantCode   int
+		wantStdout bool
+		wantQuery  url.Values
+		wantStderr string
+	}{
+		{
+			name:       "stdout",
+			args:       []string{"--api-key", testAPIKey, "--type", "png", "example.com"},
+			wantStdout: true,
+			wantQuery:  url.Values{"url": {"example.com"}, "type": {"png"}},
+		},
+		{
+			name: "options",
+			args: []string{"--api-key", testAPIKey, "--device", "iphone-14", "--width", "500",
+				"--cookie", "a=1", "--cookie", "b=2", "--full-page", "--quality", "90", "example.com"},
+			wantStdout: true,
+			wantQuery: url.Values{
+				"width":    {"500"},
+				"height":   {"844"},
+				"mobile":   {"true"},
+				"fullPage": {"true"},
+				"quality":  {"90"},
+			},
+		},
+		{
+			name:       "env",
+			args:       []string{"--config", filepath.Join(dir, "missing.json"), "example.com"},
+			getenv:     func(name string) string { return map[string]string{apiKeyEnv: testAPIKey}[name] },
+			wantStdout: true,
+		},
+		{
+			name:       "config",
+			args:       []string{"--config", configPath, "example.com"},
+			wantStdout: true,
+		},
+		{
+			name:       "missing config",
+			args:       []string{"--config", filepath.Join(dir, "missing.json"), "example.com"},
+			wantCode:   exitUsage,
+			wantStderr: "missing.json",
+		},
+		{
+			name:       "invalid option",
+			args:       []string{"--api-key", testAPIKey, "--type", "gif", "--width", "1", "example.com"},
+			wantCode:   exitUsage,
+			wantStderr: "imageType",
+		},
+		{
+			name:     "no url",
+			args:     []string{"--api-key", testAPIKey},
+			wantCode: exitUsage,
+		},
+		{
+			name:       "invalid api key",
+			args:       []string{"--api-key", "at_wrong", "example.com"},
+			wantCode:   exitInvalidAPIKey,
+			wantStderr: "screenshot: API error 401: Invalid API key.",
+		},
+		{
+			name:       "insufficient credits",
+			args:       []string{"--api-key", testAPIKey, "nocredits.com"},
+			wantCode:   exitInsufficientCredits,
+			wantStderr: "API error 403",
+		},
+		{
+			name:       "hostname changed",
+			args:       []string{"--api-key", testAPIKey, "-o", filepath.Join(dir, "redirect.jpg"), "redirect.com"},
+			wantCode:   exitHostnameChanged,
+			wantStderr: "API error 422",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := tt.getenv
+			if getenv == nil {
+				getenv = noEnv
+			}
+
+			var stdout, stderr bytes.Buffer
+
+			code := run(context.Background(), append([]string{"--base-url", server.URL}, tt.args...),
+				nil, &stdout, &stderr, getenv)
+			if code != tt.wantCode {
+				t.Fatalf("run() = %d, want %d, stderr: %s", code, tt.wantCode, stderr.String())
+			}
+
+			if got := bytes.Equal(stdout.Bytes(), pngImage(10, 10)); got != tt.wantStdout {
+				t.Errorf("stdout is the screenshot = %v, want %v", got, tt.wantStdout)
+			}
+
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want to contain %q", stderr.String(), tt.wantStderr)
+			}
+
+			for name := range tt.wantQuery {
+				if got, want := last.Get(name), tt.wantQuery.Get(name); got != want {
+					t.Errorf("query %s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestRunOutputFile tests writing the screenshot to a file.
+func TestRunOutputFile(t *testing.T) {
+	var last url.Values
+
+	server := newServer(&last)
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+
+	args := []string{"--base-url", server.URL, "--api-key", testAPIKey, "--type", "png",
+		"--cookie", "session=abc", "-o", filepath.Join(dir, "{{.Host}}", "shot.{{.Ext}}"), "example.com"}
+
+	if code := run(context.Background(), args, nil, &stdout, &stderr, os.Getenv); code != exitOK {
+		t.Fatalf("run() = %d, stderr: %s", code, stderr.String())
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "example.com", "shot.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, pngImage(10, 10)) {
+		t.Error("file content mismatch")
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("stdout = %q, want empty", stdout.String())
+	}
+	if got := last.Get("cookies"); got != "session=abc" {
+		t.Errorf("cookies = %q, want session=abc", got)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	screenshotapi "github.com/whois-api-llc/screenshot-go"
+)
+
+// cookieFlag collects repeated --cookie name=value flags.
+type cookieFlag screenshotapi.Cookies
+
+// String implements flag.Value.
+func (c cookieFlag) String() string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+c[name])
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value.
+func (c cookieFlag) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("must be name=value")
+	}
+
+	c[s[:i]] = s[i+1:]
+
+	return nil
+}
+
+// optionFlags are the flags mapped to the library options.
+type optionFlags struct {
+	errorsOutputFormat   string
+	imageOutputFormat    string
+	credits              string
+	imageType            string
+	quality              int
+	width                int
+	height               int
+	thumbWidth           int
+	mode                 string
+	scroll               bool
+	scrollPosition       string
+	fullPage             bool
+	noJs                 bool
+	delay                int
+	timeout              int
+	scale                float64
+	retina               bool
+	ua                   string
+	cookies              cookieFlag
+	mobile               bool
+	touchScreen          bool
+	landscape            bool
+	failOnHostnameChange bool
+	device               string
+
+	// options maps the flag name to the option it sets, except the device
+	options map[string]func() screenshotapi.Option
+}
+
+// register defines the option flags in the flag set.
+func (o *optionFlags) register(fs *flag.FlagSet) {
+	o.cookies = cookieFlag{}
+
+	fs.StringVar(&o.errorsOutputFormat, "errors-output-format", "", "errors output format: JSON | XML")
+	fs.StringVar(&o.imageOutputFormat, "output-format", "", "response output format: image | base64")
+	fs.StringVar(&o.credits, "credits", "", "credits type: SA | DRS")
+	fs.StringVar(&o.imageType, "type", "", "image output type: jpg | png | pdf")
+	fs.IntVar(&o.quality, "quality", 0, "image quality, only for jpg")
+	fs.IntVar(&o.width, "width", 0, "image width (px)")
+	fs.IntVar(&o.height, "height", 0, "image height (px)")
+	fs.IntVar(&o.thumbWidth, "thumb-width", 0, "image thumb width (px)")
+	fs.StringVar(&o.mode, "mode", "", "page load mode: fast | slow")
+	fs.BoolVar(&o.scroll, "scroll", false, "scroll the page before capturing")
+	fs.StringVar(&o.scrollPosition, "scroll-position", "", "scroll position: top | bottom")
+	fs.BoolVar(&o.fullPage, "full-page", false, "capture the full page")
+	fs.BoolVar(&o.noJs, "no-js", false, "disable JavaScript")
+	fs.IntVar(&o.delay, "delay", 0, "delay before capturing (ms)")
+	fs.IntVar(&o.timeout, "timeout", 0, "page load timeout (ms)")
+	fs.Float64Var(&o.scale, "scale", 0, "device scale factor")
+	fs.BoolVar(&o.retina, "retina", false, "emulate a retina display")
+	fs.StringVar(&o.ua, "ua", "", "User-Agent header")
+	fs.Var(o.cookies, "cookie", "cookie as name=value, may be repeated")
+	fs.BoolVar(&o.mobile, "mobile", false, "emulate a mobile device")
+	fs.BoolVar(&o.touchScreen, "touch-screen", false, "emulate a touch screen")
+	fs.BoolVar(&o.landscape, "landscape", false, "render in landscape mode")
+	fs.BoolVar(&o.failOnHostnameChange, "fail-on-hostname-change", false, "fail if the hostname is changed by redirects")
+	fs.StringVar(&o.device, "device", "", "emulated device: "+strings.Join(screenshotapi.Devices(), " | "))
+
+	o.options = map[string]func() screenshotapi.Option{
+		"errors-output-format": func() screenshotapi.Option {
+			return screenshotapi.OptionErrorsOutputFormat(o.errorsOutputFormat)
+		},
+		"output-format": func() screenshotapi.Option {
+			return screenshotapi.OptionImageOutputFormat(o.imageOutputFormat)
+		},
+		"credits":         func() screenshotapi.Option { return screenshotapi.OptionCredits(o.credits) },
+		"type":            func() screenshotapi.Option { return screenshotapi.OptionType(o.imageType) },
+		"quality":         func() screenshotapi.Option { return screenshotapi.OptionQuality(o.quality) },
+		"width":           func() screenshotapi.Option { return screenshotapi.OptionWidth(o.width) },
+		"height":          func() screenshotapi.Option { return screenshotapi.OptionHeight(o.height) },
+		"thumb-width":     func() screenshotapi.Option { return screenshotapi.OptionThumbWidth(o.thumbWidth) },
+		"mode":            func() screenshotapi.Option { return screenshotapi.OptionMode(o.mode) },
+		"scroll":          func() screenshotapi.Option { return screenshotapi.OptionScroll(o.scroll) },
+		"scroll-position": func() screenshotapi.Option { return screenshotapi.OptionScrollPosition(o.scrollPosition) },
+		"full-page":       func() screenshotapi.Option { return screenshotapi.OptionFullPage(o.fullPage) },
+		"no-js":           func() screenshotapi.Option { return screenshotapi.OptionNoJs(o.noJs) },
+		"delay":           func() screenshotapi.Option { return screenshotapi.OptionDelay(o.delay) },
+		"timeout":         func() screenshotapi.Option { return screenshotapi.OptionTimeout(o.timeout) },
+		"scale":           func() screenshotapi.Option { return screenshotapi.OptionScale(o.scale) },
+		"retina":          func() screenshotapi.Option { return screenshotapi.OptionRetina(o.retina) },
+		"ua":              func() screenshotapi.Option { return screenshotapi.OptionUA(o.ua) },
+		"cookie": func() screenshotapi.Option {
+			return screenshotapi.OptionCookies(screenshotapi.Cookies(o.cookies))
+		},
+		"mobile":       func() screenshotapi.Option { return screenshotapi.OptionMobile(o.mobile) },
+		"touch-screen": func() screenshotapi.Option { return screenshotapi.OptionTouchScreen(o.touchScreen) },
+		"landscape":    func() screenshotapi.Option { return screenshotapi.OptionLandscape(o.landscape) },
+		"fail-on-hostname-change": func() screenshotapi.Option {
+			return screenshotapi.OptionFailOnHostnameChange(o.failOnHostnameChange)
+		},
+	}
+}
+
+// Options returns the options of the flags set on the command line. The device goes first,
+// so explicit flags override its settings.
+func (o *optionFlags) Options(fs *flag.FlagSet) []screenshotapi.Option {
+	var (
+		opts      []screenshotapi.Option
+		deviceSet bool
+	)
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "device" {
+			deviceSet = true
+		} else if option, ok := o.options[f.Name]; ok {
+			opts = append(opts, option())
+		}
+	})
+
+	if !deviceSet {
+		return opts
+	}
+
+	return append([]screenshotapi.Option{screenshotapi.OptionDevice(o.device)}, opts...)
+}
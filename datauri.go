@@ -0,0 +1,118 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// defaultDataURIMediaType is the media type of data URIs which omit it (RFC 2397).
+const defaultDataURIMediaType = "text/plain"
+
+// paramDecodeDataURI is the query parameter set by OptionDecodeDataURI. It's removed before the request is sent.
+const paramDecodeDataURI = "decodeDataURI"
+
+var dataURIPrefix = []byte("data:")
+
+// OptionDecodeDataURI if true, GetRaw and Capture decode the base64 data URI response
+// (see OptionImageOutputFormat), so the body is always binary image data and Response.MediaType
+// is its declared MIME type. The option is handled by the client and isn't sent to the API.
+func OptionDecodeDataURI(decode bool) Option {
+	return func(v url.Values) error {
+		if decode {
+			v.Set(paramDecodeDataURI, "true")
+		}
+		return nil
+	}
+}
+
+// DataURI is the parsed data URI, e.g. data:image/jpeg;base64,/9j/4AAQ...
+// The Screenshot API returns screenshots as data URIs when OptionImageOutputFormat("base64") is set.
+type DataURI struct {
+	// MediaType is the declared MIME type, e.g. image/jpeg
+	MediaType string
+
+	// Params are the media type parameters, e.g. charset
+	Params map[string]string
+
+	// Base64 is true if the data is base64-encoded
+	Base64 bool
+
+	// Data is the decoded data
+	Data []byte
+}
+
+// IsDataURI reports whether raw starts with the data URI scheme.
+func IsDataURI(raw []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(raw), dataURIPrefix)
+}
+
+// ParseDataURI parses the data URI and decodes its data.
+func ParseDataURI(raw []byte) (*DataURI, error) {
+	raw = bytes.TrimSpace(raw)
+
+	if !bytes.HasPrefix(raw, dataURIPrefix) {
+		return nil, errors.New("invalid data URI: missing data: scheme")
+	}
+	raw = raw[len(dataURIPrefix):]
+
+	comma := bytes.IndexByte(raw, ',')
+	if comma < 0 {
+		return nil, errors.New("invalid data URI: missing comma")
+	}
+
+	header, data := string(raw[:comma]), raw[comma+1:]
+
+	uri := &DataURI{
+		MediaType: defaultDataURIMediaType,
+		Params:    map[string]string{},
+	}
+
+	for i, part := range strings.Split(header, ";") {
+		part = strings.TrimSpace(part)
+
+		switch {
+		case i == 0:
+			if part != "" {
+				uri.MediaType = strings.ToLower(part)
+			}
+		case strings.EqualFold(part, "base64"):
+			uri.Base64 = true
+		case part != "":
+			kv := strings.SplitN(part, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid data URI: malformed parameter %q", part)
+			}
+			uri.Params[strings.ToLower(kv[0])] = kv[1]
+		}
+	}
+
+	if !uri.Base64 {
+		unescaped, err := url.PathUnescape(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid data URI: %w", err)
+		}
+		uri.Data = []byte(unescaped)
+		return uri, nil
+	}
+
+	encoded := bytes.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, data)
+
+	uri.Data = make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+
+	n, err := base64.StdEncoding.Decode(uri.Data, encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data URI: %w", err)
+	}
+	uri.Data = uri.Data[:n]
+
+	return uri, nil
+}
@@ -0,0 +1,109 @@
+package screenshotapi
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// TestParseDataURI tests the ParseDataURI function.
+func TestParseDataURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    *DataURI
+		wantErr bool
+	}{
+		{
+			name: "base64 image",
+			raw:  "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString([]byte{0xFF, 0xD8, 0xFF, 0xE0}),
+			want: &DataURI{MediaType: "image/jpeg", Params: map[string]string{}, Base64: true, Data: []byte{0xFF, 0xD8, 0xFF, 0xE0}},
+		},
+		{
+			name: "parameters",
+			raw:  "data:Text/Plain;charset=utf-8;base64,aGVsbG8=\n",
+			want: &DataURI{MediaType: "text/plain", Params: map[string]string{"charset": "utf-8"}, Base64: true, Data: []byte("hello")},
+		},
+		{
+			name: "percent-encoded",
+			raw:  "data:,hello%20world",
+			want: &DataURI{MediaType: "text/plain", Params: map[string]string{}, Data: []byte("hello world")},
+		},
+		{
+			name:    "missing scheme",
+			raw:     "image/jpeg;base64,aGVsbG8=",
+			wantErr: true,
+		},
+		{
+			name:    "missing comma",
+			raw:     "data:image/jpeg;base64",
+			wantErr: true,
+		},
+		{
+			name:    "corrupted base64",
+			raw:     "data:image/jpeg;base64,aGVsb!8=",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDataURI([]byte(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDataURI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDataURI() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDecodeDataURI tests decoding base64 responses by GetRaw and Capture.
+func TestDecodeDataURI(t *testing.T) {
+	img := pngImage(100, 100)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("data:image/png;base64," + base64.StdEncoding.EncodeToString(img)))
+	}))
+	defer server.Close()
+
+	apiURL, _ := url.Parse(server.URL)
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+	})
+
+	resp, err := api.GetRaw(context.Background(), "whoisxmlapi.com",
+		OptionImageOutputFormat("base64"), OptionDecodeDataURI(true))
+	if err != nil {
+		t.Fatalf("ScreenshotAPI.GetRaw() error = %v", err)
+	}
+	if !reflect.DeepEqual(resp.Body, img) || resp.MediaType != "image/png" {
+		t.Errorf("ScreenshotAPI.GetRaw() media type = %s, body is not decoded", resp.MediaType)
+	}
+	if resp.Request.URL.Query().Get(paramDecodeDataURI) != "" {
+		t.Errorf("ScreenshotAPI.GetRaw() sent the client-side option: %s", resp.Request.URL.RawQuery)
+	}
+
+	// the same client returns the raw body without the option
+	resp, err = api.GetRaw(context.Background(), "whoisxmlapi.com", OptionImageOutputFormat("base64"))
+	if err != nil {
+		t.Fatalf("ScreenshotAPI.GetRaw() error = %v", err)
+	}
+	if !IsDataURI(resp.Body) || resp.MediaType != "" {
+		t.Errorf("ScreenshotAPI.GetRaw() media type = %s, body is decoded", resp.MediaType)
+	}
+
+	res, err := api.Capture(context.Background(), "whoisxmlapi.com",
+		OptionImageOutputFormat("base64"), OptionDecodeDataURI(true))
+	if err != nil {
+		t.Fatalf("ScreenshotAPI.Capture() error = %v", err)
+	}
+	if res.Format != FormatPNG || res.ContentType != "image/png" || res.Width != 100 {
+		t.Errorf("ScreenshotAPI.Capture() = %v %s %d", res.Format, res.ContentType, res.Width)
+	}
+}
@@ -0,0 +1,175 @@
+package screenshotapi
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// landscapeSuffix is appended to the device name to get its landscape variant, e.g. iphone-14-landscape.
+const landscapeSuffix = "-landscape"
+
+// Device is the emulated device profile.
+type Device struct {
+	// Name is the device name used by OptionDevice, e.g. iphone-14
+	Name string
+
+	// Width and Height are the viewport size in CSS pixels in the default orientation
+	Width  int
+	Height int
+
+	// Scale is the device pixel ratio
+	Scale float64
+
+	// Mobile if true, the API emulates mobile device
+	Mobile bool
+
+	// TouchScreen if true, the API emulates device with a touch screen
+	TouchScreen bool
+
+	// Landscape if true, the page is rendered in landscape mode
+	Landscape bool
+
+	// UA is the 'User-Agent' header string
+	UA string
+}
+
+// Options returns the Option functions emulating the device.
+func (d Device) Options() []Option {
+	opts := []Option{
+		OptionWidth(d.Width),
+		OptionHeight(d.Height),
+	}
+
+	if d.Scale != 0 {
+		opts = append(opts, OptionScale(d.Scale))
+	}
+	if d.UA != "" {
+		opts = append(opts, OptionUA(d.UA))
+	}
+
+	return append(opts,
+		OptionMobile(d.Mobile),
+		OptionTouchScreen(d.TouchScreen),
+		OptionLandscape(d.Landscape),
+	)
+}
+
+// Rotate returns the landscape variant of the device and vice versa.
+// Width and Height are swapped, the landscape mode is only set for mobile devices.
+func (d Device) Rotate() Device {
+	r := d
+	r.Width, r.Height = d.Height, d.Width
+
+	if d.Landscape {
+		r.Name = strings.TrimSuffix(d.Name, landscapeSuffix)
+		r.Landscape = false
+	} else {
+		r.Name = d.Name + landscapeSuffix
+		r.Landscape = d.Mobile
+	}
+
+	return r
+}
+
+const (
+	uaIPhone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 16_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.0 Mobile/15E148 Safari/604.1"
+	uaIPad    = "Mozilla/5.0 (iPad; CPU OS 16_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.0 Mobile/15E148 Safari/604.1"
+	uaPixel   = "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/107.0.0.0 Mobile Safari/537.36"
+	uaGalaxy  = "Mozilla/5.0 (Linux; Android 12; SM-S901B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/107.0.0.0 Mobile Safari/537.36"
+	uaDesktop = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/107.0.0.0 Safari/537.36"
+)
+
+var (
+	devicesMu sync.RWMutex
+	devices   = map[string]Device{}
+)
+
+func init() {
+	for _, d := range []Device{
+		{Name: "iphone-se", Width: 375, Height: 667, Scale: 2, Mobile: true, TouchScreen: true, UA: uaIPhone},
+		{Name: "iphone-14", Width: 390, Height: 844, Scale: 3, Mobile: true, TouchScreen: true, UA: uaIPhone},
+		{Name: "iphone-14-pro-max", Width: 430, Height: 932, Scale: 3, Mobile: true, TouchScreen: true, UA: uaIPhone},
+		{Name: "pixel-7", Width: 412, Height: 915, Scale: 2.625, Mobile: true, TouchScreen: true, UA: uaPixel},
+		{Name: "galaxy-s22", Width: 360, Height: 780, Scale: 3, Mobile: true, TouchScreen: true, UA: uaGalaxy},
+		{Name: "ipad", Width: 810, Height: 1080, Scale: 2, Mobile: true, TouchScreen: true, UA: uaIPad},
+		{Name: "ipad-pro-11", Width: 834, Height: 1194, Scale: 2, Mobile: true, TouchScreen: true, UA: uaIPad},
+		{Name: "desktop-720p", Width: 1280, Height: 720, Scale: 1, UA: uaDesktop},
+		{Name: "desktop-1080p", Width: 1920, Height: 1080, Scale: 1, UA: uaDesktop},
+		{Name: "desktop-1440p", Width: 2560, Height: 1440, Scale: 1, UA: uaDesktop},
+	} {
+		devices[d.Name] = d
+	}
+}
+
+// RegisterDevice adds the device profile to the catalogue or replaces the existing one with the same name.
+// The landscape variant is available as Name + "-landscape".
+func RegisterDevice(d Device) error {
+	if d.Name == "" {
+		return &ArgError{"Device.Name", "can not be empty"}
+	}
+	if strings.HasSuffix(d.Name, landscapeSuffix) {
+		return &ArgError{"Device.Name", "can not end with " + landscapeSuffix}
+	}
+	if errs, custom := applyOptions(url.Values{}, false, d.Options()...); len(errs)+len(custom) != 0 {
+		return joinOptionErrors(errs, custom)
+	}
+
+	devicesMu.Lock()
+	defer devicesMu.Unlock()
+
+	devices[strings.ToLower(d.Name)] = d
+
+	return nil
+}
+
+// LookupDevice returns the device profile by name. Append "-landscape" to the name for the landscape variant.
+func LookupDevice(name string) (Device, bool) {
+	name = strings.ToLower(name)
+
+	devicesMu.RLock()
+	defer devicesMu.RUnlock()
+
+	if d, ok := devices[name]; ok {
+		return d, true
+	}
+
+	if d, ok := devices[strings.TrimSuffix(name, landscapeSuffix)]; ok && strings.HasSuffix(name, landscapeSuffix) {
+		return d.Rotate(), true
+	}
+
+	return Device{}, false
+}
+
+// Devices returns the sorted names of the registered devices in the default orientation.
+func Devices() []string {
+	devicesMu.RLock()
+	defer devicesMu.RUnlock()
+
+	names := make([]string, 0, len(devices))
+	for name := range devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// OptionDevice emulates the device from the catalogue: sets width, height, scale, user agent, mobile,
+// touch screen and landscape options. Acceptable values: names returned by Devices, optionally with
+// the "-landscape" suffix. Options passed after OptionDevice override the device settings.
+func OptionDevice(name string) Option {
+	return func(v url.Values) error {
+		d, ok := LookupDevice(name)
+		if !ok {
+			return &ArgError{"device", "must be one of " + strings.Join(Devices(), " | ")}
+		}
+		for _, opt := range d.Options() {
+			if err := opt(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
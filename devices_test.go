@@ -0,0 +1,78 @@
+package screenshotapi
+
+import (
+	"net/url"
+	"testing"
+)
+
+// TestOptionDevice tests the OptionDevice function.
+func TestOptionDevice(t *testing.T) {
+	tests := []struct {
+		name    string
+		device  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "portrait",
+			device: "iphone-14",
+			want:   map[string]string{"width": "390", "height": "844", "mobile": "true", "landscape": ""},
+		},
+		{
+			name:   "landscape",
+			device: "iPhone-14-Landscape",
+			want:   map[string]string{"width": "844", "height": "390", "mobile": "true", "landscape": "true"},
+		},
+		{
+			name:   "desktop landscape",
+			device: "desktop-1080p-landscape",
+			want:   map[string]string{"width": "1080", "height": "1920", "mobile": "", "landscape": ""},
+		},
+		{
+			name:    "unknown",
+			device:  "nokia-3310",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := url.Values{}
+
+			err := OptionDevice(tt.device)(v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OptionDevice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			for key, want := range tt.want {
+				if got := v.Get(key); got != want {
+					t.Errorf("OptionDevice() %s = %q, want %q", key, got, want)
+				}
+			}
+
+			if err == nil {
+				if verr := ValidateOptions(OptionDevice(tt.device)); verr != nil {
+					t.Errorf("ValidateOptions(OptionDevice()) error = %v", verr)
+				}
+			}
+		})
+	}
+}
+
+// TestRegisterDevice tests the RegisterDevice function.
+func TestRegisterDevice(t *testing.T) {
+	if err := RegisterDevice(Device{Name: "kiosk", Width: 1080, Height: 1920, TouchScreen: true}); err != nil {
+		t.Fatalf("RegisterDevice() error = %v", err)
+	}
+
+	if d, ok := LookupDevice("kiosk-landscape"); !ok || d.Width != 1920 || d.Name != "kiosk-landscape" {
+		t.Errorf("LookupDevice() = %+v, %v", d, ok)
+	}
+
+	if err := RegisterDevice(Device{Name: "tiny", Width: 10, Height: 10}); err == nil {
+		t.Errorf("RegisterDevice() error = nil, want invalid width")
+	}
+
+	if err := RegisterDevice(Device{Name: "kiosk-landscape", Width: 1920, Height: 1080}); err == nil {
+		t.Errorf("RegisterDevice() error = nil, want invalid name")
+	}
+}
@@ -0,0 +1,100 @@
+package screenshotapi
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error classes of Screenshot API failures. Errors returned by the client can be matched against them
+// with errors.Is:
+//
+//	if errors.Is(err, screenshotapi.ErrInsufficientCredits) {
+//		// top up the balance
+//	}
+var (
+	// ErrBadRequest is returned when the API rejects request parameters.
+	ErrBadRequest = errors.New("bad request")
+
+	// ErrInvalidAPIKey is returned when the API key is missing or invalid.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+
+	// ErrInsufficientCredits is returned when the account has no credits left or access is restricted.
+	ErrInsufficientCredits = errors.New("insufficient credits")
+
+	// ErrPageTimeout is returned when the target page is not loaded within the timeout.
+	ErrPageTimeout = errors.New("target page timeout")
+
+	// ErrHostnameChanged is returned when the target hostname is changed due to redirects
+	// and OptionFailOnHostnameChange is set.
+	ErrHostnameChanged = errors.New("hostname changed")
+
+	// ErrRateLimited is returned when the rate limit of the account is exceeded.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrServerError is returned when the API fails to process the request.
+	ErrServerError = errors.New("server error")
+
+	// ErrInvalidBody is returned when the response body doesn't match the requested image type
+	// or is truncated. See ValidationError.
+	ErrInvalidBody = errors.New("invalid response body")
+)
+
+// errorClass returns the error class for the Screenshot API error code or HTTP status code.
+func errorClass(code int) error {
+	switch {
+	case code == http.StatusBadRequest:
+		return ErrBadRequest
+	case code == http.StatusUnauthorized:
+		return ErrInvalidAPIKey
+	case code == http.StatusPaymentRequired || code == http.StatusForbidden:
+		return ErrInsufficientCredits
+	case code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout:
+		return ErrPageTimeout
+	case code == http.StatusUnprocessableEntity:
+		return ErrHostnameChanged
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code >= 500 && code <= 599:
+		return ErrServerError
+	}
+
+	return nil
+}
+
+// ErrorClass returns the error class of err (one of ErrBadRequest, ErrInvalidAPIKey, ErrInsufficientCredits,
+// ErrPageTimeout, ErrHostnameChanged, ErrRateLimited, ErrServerError, ErrInvalidBody)
+// or nil if err doesn't belong to any.
+func ErrorClass(err error) error {
+	for _, class := range []error{
+		ErrBadRequest,
+		ErrInvalidAPIKey,
+		ErrInsufficientCredits,
+		ErrPageTimeout,
+		ErrHostnameChanged,
+		ErrRateLimited,
+		ErrServerError,
+		ErrInvalidBody,
+	} {
+		if errors.Is(err, class) {
+			return class
+		}
+	}
+
+	return nil
+}
+
+// Is reports whether the error message belongs to the error class target.
+func (e *ErrorMessage) Is(target error) bool {
+	class := errorClass(e.Code)
+	return class != nil && class == target
+}
+
+// Is reports whether the error response belongs to the error class target.
+func (e *ErrorResponse) Is(target error) bool {
+	if e.Response == nil {
+		return false
+	}
+
+	class := errorClass(e.Response.StatusCode)
+	return class != nil && class == target
+}
@@ -0,0 +1,69 @@
+package screenshotapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// TestErrorClass tests matching errors against the error classes.
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "invalid API key",
+			err:  &ErrorMessage{Code: 401, Message: "Authentication failed"},
+			want: ErrInvalidAPIKey,
+		},
+		{
+			name: "insufficient credits",
+			err:  &ErrorMessage{Code: 403, Message: "Access restricted. Check credits balance"},
+			want: ErrInsufficientCredits,
+		},
+		{
+			name: "hostname changed",
+			err:  fmt.Errorf("wrapped: %w", &ErrorMessage{Code: 422, Message: "Hostname changed"}),
+			want: ErrHostnameChanged,
+		},
+		{
+			name: "rate limited",
+			err:  &ErrorResponse{Response: &http.Response{StatusCode: 429}},
+			want: ErrRateLimited,
+		},
+		{
+			name: "server error",
+			err:  &ErrorResponse{Response: &http.Response{StatusCode: 503}},
+			want: ErrServerError,
+		},
+		{
+			name: "page timeout",
+			err:  &ErrorMessage{Code: 408, Message: "Timeout"},
+			want: ErrPageTimeout,
+		},
+		{
+			name: "unknown code",
+			err:  &ErrorMessage{Code: 499, Message: "Test error message."},
+			want: nil,
+		},
+		{
+			name: "not an API error",
+			err:  context.Canceled,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorClass(tt.err); got != tt.want {
+				t.Errorf("ErrorClass() = %v, want %v", got, tt.want)
+			}
+			if tt.want != nil && !errors.Is(tt.err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", tt.err, tt.want)
+			}
+		})
+	}
+}
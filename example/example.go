@@ -24,6 +24,10 @@ func GetData(apikey string) {
 			log.Println(apiErr.Code)
 			log.Println(apiErr.Message)
 		}
+		// Handle the class of error.
+		if errors.Is(err, screenshotapi.ErrInsufficientCredits) {
+			log.Fatal("out of credits")
+		}
 		log.Fatal(err)
 	}
 
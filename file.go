@@ -0,0 +1,200 @@
+package screenshotapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OverwritePolicy specifies what happens when the output file already exists.
+type OverwritePolicy int
+
+const (
+	// OverwriteAlways replaces the existing file.
+	OverwriteAlways OverwritePolicy = iota
+
+	// OverwriteNever fails with an error satisfying errors.Is(err, os.ErrExist).
+	// The file is published with a hard link, or created exclusively on file systems without hard links.
+	OverwriteNever
+
+	// OverwriteUnique keeps the existing file and picks a unique name by appending a numeric suffix:
+	// name-1.jpg, name-2.jpg, etc.
+	OverwriteUnique
+)
+
+const (
+	// DefaultFilePerm is the default permission bits of the output file.
+	DefaultFilePerm os.FileMode = 0644
+
+	// DefaultDirPerm is the permission bits of the created parent directories.
+	DefaultDirPerm os.FileMode = 0755
+
+	// maxUniqueSuffix is the maximum numeric suffix tried by OverwriteUnique.
+	maxUniqueSuffix = 10000
+)
+
+// FileParams specifies how output files are written. The zero value overwrites existing files
+// with DefaultFilePerm permissions and requires the parent directory to exist.
+type FileParams struct {
+	// Overwrite specifies what happens when the output file already exists
+	Overwrite OverwritePolicy
+
+	// Perm is the permission bits of the output file
+	// If it's zero then DefaultFilePerm is used
+	Perm os.FileMode
+
+	// CreateDirs if true, missing parent directories are created with DefaultDirPerm permissions
+	CreateDirs bool
+}
+
+// WriteFile writes the content of r to filename atomically: the data goes to a temporary file
+// in the same directory which is renamed on success, so a failed write never leaves a truncated file.
+// It returns the name of the written file which differs from filename for OverwriteUnique.
+func WriteFile(filename string, r io.Reader, params FileParams) (name string, err error) {
+	if filename == "" {
+		return "", &ArgError{"filename", "can not be empty"}
+	}
+
+	perm := params.Perm
+	if perm == 0 {
+		perm = DefaultFilePerm
+	}
+
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	if params.CreateDirs {
+		if err = os.MkdirAll(dir, DefaultDirPerm); err != nil {
+			return "", err
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".*.tmp")
+	if err != nil {
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			return "", &os.PathError{Op: "open", Path: filename, Err: pathErr.Err}
+		}
+		return "", err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = io.Copy(tmp, r); err != nil {
+		return "", err
+	}
+
+	if err = tmp.Chmod(perm); err != nil {
+		return "", err
+	}
+
+	if err = tmp.Sync(); err != nil {
+		return "", err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+
+	switch params.Overwrite {
+	case OverwriteAlways:
+		if err = os.Rename(tmp.Name(), filename); err != nil {
+			return "", err
+		}
+		return filename, nil
+	case OverwriteNever:
+		if err = link(tmp.Name(), filename, perm); err != nil {
+			return "", err
+		}
+		return filename, nil
+	case OverwriteUnique:
+		ext := filepath.Ext(filename)
+		stem := strings.TrimSuffix(filename, ext)
+
+		for i := 0; i <= maxUniqueSuffix; i++ {
+			name = filename
+			if i > 0 {
+				name = fmt.Sprintf("%s-%d%s", stem, i, ext)
+			}
+
+			if err = link(tmp.Name(), name, perm); !errors.Is(err, os.ErrExist) {
+				if err != nil {
+					return "", err
+				}
+				return name, nil
+			}
+		}
+		return "", err
+	default:
+		err = &ArgError{"Overwrite", "must be OverwriteAlways | OverwriteNever | OverwriteUnique"}
+		return "", err
+	}
+}
+
+// osLink creates hard links. It's a variable, so tests can emulate file systems without hard links.
+var osLink = os.Link
+
+// link creates newname as a hard link to the temporary file oldname and removes oldname.
+// It fails if newname already exists, so no existing file is ever replaced. If the file system
+// doesn't support hard links, the data is copied to newname created with O_EXCL instead.
+func link(oldname, newname string, perm os.FileMode) error {
+	err := osLink(oldname, newname)
+	if err != nil && !errors.Is(err, os.ErrExist) {
+		err = copyExclusive(oldname, newname, perm)
+	}
+
+	if err != nil {
+		var linkErr *os.LinkError
+		if errors.As(err, &linkErr) {
+			return &os.PathError{Op: "create", Path: newname, Err: linkErr.Err}
+		}
+		return err
+	}
+
+	_ = os.Remove(oldname)
+
+	return nil
+}
+
+// copyExclusive copies oldname to newname which must not exist. A partially written newname is removed.
+func copyExclusive(oldname, newname string, perm os.FileMode) (err error) {
+	src, err := os.Open(oldname)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(newname, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if cerr := dst.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(newname)
+		}
+	}()
+
+	if _, err = io.Copy(dst, src); err != nil {
+		return err
+	}
+
+	if err = dst.Chmod(perm); err != nil {
+		return err
+	}
+
+	return dst.Sync()
+}
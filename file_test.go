@@ -0,0 +1,134 @@
+package screenshotapi
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// TestWriteFile tests the WriteFile function.
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+
+	existing := filepath.Join(dir, "existing.jpg")
+	if err := os.WriteFile(existing, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		params   FileParams
+		wantName string
+		wantErr  error
+	}{
+		{
+			name:     "new file",
+			filename: filepath.Join(dir, "new.jpg"),
+			wantName: filepath.Join(dir, "new.jpg"),
+		},
+		{
+			name:     "overwrite",
+			filename: existing,
+			params:   FileParams{Overwrite: OverwriteAlways, Perm: 0600},
+			wantName: existing,
+		},
+		{
+			name:     "never overwrite",
+			filename: existing,
+			params:   FileParams{Overwrite: OverwriteNever},
+			wantErr:  os.ErrExist,
+		},
+		{
+			name:     "unique name",
+			filename: existing,
+			params:   FileParams{Overwrite: OverwriteUnique},
+			wantName: filepath.Join(dir, "existing-1.jpg"),
+		},
+		{
+			name:     "missing directory",
+			filename: filepath.Join(dir, "a", "b", "c.jpg"),
+			wantErr:  os.ErrNotExist,
+		},
+		{
+			name:     "create directories",
+			filename: filepath.Join(dir, "a", "b", "c.jpg"),
+			params:   FileParams{CreateDirs: true},
+			wantName: filepath.Join(dir, "a", "b", "c.jpg"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, err := WriteFile(tt.filename, strings.NewReader("new"), tt.params)
+			if tt.wantErr != nil || err != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("WriteFile() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if name != tt.wantName {
+				t.Errorf("WriteFile() name = %v, want %v", name, tt.wantName)
+			}
+
+			data, err := os.ReadFile(name)
+			if err != nil || string(data) != "new" {
+				t.Errorf("WriteFile() content = %s, %v, want new", data, err)
+			}
+
+			if tt.params.Perm != 0 {
+				if fi, err := os.Stat(name); err != nil || fi.Mode().Perm() != tt.params.Perm {
+					t.Errorf("WriteFile() perm = %v, want %v", fi.Mode().Perm(), tt.params.Perm)
+				}
+			}
+		})
+	}
+
+	if data, _ := os.ReadFile(existing); string(data) != "new" {
+		t.Errorf("existing file content = %s, want new", data)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf("temporary files left: %v", matches)
+	}
+}
+
+// TestWriteFileNoHardLinks tests overwrite policies on file systems without hard links.
+func TestWriteFileNoHardLinks(t *testing.T) {
+	osLink = func(oldname, newname string) error {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: syscall.EPERM}
+	}
+	defer func() { osLink = os.Link }()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "a.jpg")
+
+	if _, err := WriteFile(filename, strings.NewReader("first"), FileParams{Overwrite: OverwriteNever}); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	_, err := WriteFile(filename, strings.NewReader("second"), FileParams{Overwrite: OverwriteNever})
+	if !errors.Is(err, os.ErrExist) {
+		t.Errorf("WriteFile() error = %v, want %v", err, os.ErrExist)
+	}
+
+	name, err := WriteFile(filename, strings.NewReader("third"), FileParams{Overwrite: OverwriteUnique})
+	if err != nil || name != filepath.Join(dir, "a-1.jpg") {
+		t.Fatalf("WriteFile() = %v, %v, want %v", name, err, filepath.Join(dir, "a-1.jpg"))
+	}
+
+	for name, want := range map[string]string{filename: "first", filepath.Join(dir, "a-1.jpg"): "third"} {
+		if data, err := os.ReadFile(name); err != nil || string(data) != want {
+			t.Errorf("%s content = %s, %v, want %s", name, data, err, want)
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf("temporary files left: %v", matches)
+	}
+}
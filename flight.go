@@ -0,0 +1,152 @@
+package screenshotapi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// flightCall is the upstream call shared by concurrent identical requests.
+type flightCall struct {
+	done chan struct{}
+	resp *Response
+	err  error
+
+	// waiters is the number of callers still waiting for the result
+	waiters int
+	cancel  context.CancelFunc
+
+	// claimed is set when the first caller takes the result, the others get it marked as Shared
+	claimed bool
+}
+
+// flightGroup coalesces concurrent identical requests into a single upstream call.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// do calls fn once for all concurrent callers with the same key and returns every caller its own copy
+// of the response. A caller whose ctx is done stops waiting and gets ctx.Err(). The upstream call
+// is canceled only when no callers are left.
+func (g *flightGroup) do(
+	ctx context.Context,
+	key string,
+	fn func(ctx context.Context) (*Response, error),
+) (*Response, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*flightCall{}
+	}
+
+	call, ok := g.calls[key]
+	if !ok {
+		// the upstream call outlives the first caller if others are waiting for it
+		callCtx, cancel := context.WithCancel(detachedContext{ctx})
+		call = &flightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+
+		go func() {
+			call.resp, call.err = fn(callCtx)
+
+			g.mu.Lock()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+
+			cancel()
+			close(call.done)
+		}()
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		resp := call.resp.clone()
+
+		g.mu.Lock()
+		if call.claimed && resp != nil {
+			resp.Shared = true
+		}
+		call.claimed = true
+		g.mu.Unlock()
+
+		return resp, call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			// the next caller must not join the canceled call
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+
+		return nil, ctx.Err()
+	}
+}
+
+// clone returns the copy of the response with its own body and header.
+func (r *Response) clone() *Response {
+	if r == nil {
+		return nil
+	}
+
+	c := &Response{
+		MediaType: r.MediaType,
+		Cached:    r.Cached,
+		Shared:    r.Shared,
+	}
+
+	if r.Body != nil {
+		c.Body = append([]byte(nil), r.Body...)
+	}
+
+	if r.Response != nil {
+		httpResp := *r.Response
+		httpResp.Header = r.Response.Header.Clone()
+		c.Response = &httpResp
+	}
+
+	return c
+}
+
+// detachedContext keeps the values of the parent context but is never canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+// Deadline implements context.Context.
+func (detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return time.Time{}, false
+}
+
+// Done implements context.Context.
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+// Err implements context.Context.
+func (detachedContext) Err() error {
+	return nil
+}
+
+// Value implements context.Context.
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// flightKey returns the key identifying identical requests: the endpoint and the canonical query,
+// so the order of options and cookies doesn't matter.
+func flightKey(req *http.Request) string {
+	endpoint := *req.URL
+	endpoint.RawQuery = ""
+	endpoint.Fragment = ""
+
+	return req.Method + " " + endpoint.String() + "?" + canonicalRequestQuery(req.URL.Query()).Encode()
+}
@@ -0,0 +1,259 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitWaiters waits until the call with the key has n waiters.
+func waitWaiters(t *testing.T, g *flightGroup, key string, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		call, ok := g.calls[key]
+		done := ok && call.waiters == n
+		g.mu.Unlock()
+
+		if done {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatalf("call %s has no %d waiters", key, n)
+}
+
+// TestFlightGroup tests coalescing of concurrent calls.
+func TestFlightGroup(t *testing.T) {
+	const callers = 5
+
+	t.Run("shared result", func(t *testing.T) {
+		var (
+			g       flightGroup
+			calls   int32
+			release = make(chan struct{})
+			wg      sync.WaitGroup
+		)
+
+		fn := func(ctx context.Context) (*Response, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return &Response{Response: &http.Response{StatusCode: 200, Header: http.Header{}}, Body: []byte("body")}, nil
+		}
+
+		responses := make([]*Response, callers)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				resp, err := g.do(context.Background(), "key", fn)
+				if err != nil {
+					t.Error(err)
+				}
+				responses[i] = resp
+			}(i)
+		}
+
+		waitWaiters(t, &g, "key", callers)
+		close(release)
+		wg.Wait()
+
+		if calls != 1 {
+			t.Errorf("calls = %d, want 1", calls)
+		}
+
+		shared := 0
+		for _, resp := range responses {
+			if resp.Shared {
+				shared++
+			}
+		}
+		if shared != callers-1 {
+			t.Errorf("shared responses = %d, want %d", shared, callers-1)
+		}
+
+		responses[0].Body[0] = 'B'
+		responses[0].Header.Set("X-Test", "1")
+		for _, resp := range responses[1:] {
+			if !bytes.Equal(resp.Body, []byte("body")) || resp.Header.Get("X-Test") != "" {
+				t.Fatal("responses share the body or header")
+			}
+		}
+	})
+
+	t.Run("canceled caller", func(t *testing.T) {
+		var (
+			g       flightGroup
+			release = make(chan struct{})
+			result  = make(chan error, 1)
+		)
+
+		fn := func(ctx context.Context) (*Response, error) {
+			select {
+			case <-release:
+				return &Response{Body: []byte("body")}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		go func() {
+			_, err := g.do(context.Background(), "key", fn)
+			result <- err
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			waitWaiters(t, &g, "key", 2)
+			cancel()
+		}()
+
+		if _, err := g.do(ctx, "key", fn); !errors.Is(err, context.Canceled) {
+			t.Errorf("do() error = %v, want %v", err, context.Canceled)
+		}
+
+		// the remaining caller still gets the result
+		close(release)
+		if err := <-result; err != nil {
+			t.Errorf("do() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("all callers canceled", func(t *testing.T) {
+		var g flightGroup
+
+		upstream := make(chan error, 1)
+
+		fn := func(ctx context.Context) (*Response, error) {
+			<-ctx.Done()
+			upstream <- ctx.Err()
+			return nil, ctx.Err()
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			waitWaiters(t, &g, "key", 1)
+			cancel()
+		}()
+
+		if _, err := g.do(ctx, "key", fn); !errors.Is(err, context.Canceled) {
+			t.Errorf("do() error = %v, want %v", err, context.Canceled)
+		}
+
+		select {
+		case err := <-upstream:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("upstream error = %v, want %v", err, context.Canceled)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("upstream call is not canceled")
+		}
+	})
+}
+
+// TestScreenshotAPICoalescing tests that concurrent identical requests share a single API call.
+func TestScreenshotAPICoalescing(t *testing.T) {
+	const callers = 5
+
+	var calls int32
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		_, _ = w.Write(pngImage(10, 10))
+	}))
+	defer server.Close()
+
+	apiURL, _ := url.Parse(server.URL)
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+	})
+
+	req, _, err := api.ScreenshotAPIService.(*screenshotAPIServiceOp).captureRequest("example.com", OptionType("png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, err := api.GetRaw(context.Background(), "example.com", OptionType("png"))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !bytes.Equal(resp.Body, pngImage(10, 10)) {
+				t.Error("GetRaw() body mismatch")
+			}
+		}()
+	}
+
+	waitWaiters(t, api.flights, flightKey(req), callers)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("API calls = %d, want 1", calls)
+	}
+}
+
+// TestFlightKey tests the flightKey function.
+func TestFlightKey(t *testing.T) {
+	newRequest := func(rawURL string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	const endpoint = "https://website-screenshot.whoisxmlapi.com/api/v1"
+
+	base := flightKey(newRequest(endpoint + "?url=example.com&type=png&cookies=a%3D1%3Bb%3D2"))
+
+	tests := []struct {
+		name string
+		url  string
+		same bool
+	}{
+		{
+			name: "cookie order",
+			url:  endpoint + "?cookies=b%3D2%3Ba%3D1&type=png&url=example.com",
+			same: true,
+		},
+		{
+			name: "different cookies",
+			url:  endpoint + "?url=example.com&type=png&cookies=a%3D1%3Bb%3D3",
+		},
+		{
+			name: "different endpoint",
+			url:  "https://example.org/api/v1?url=example.com&type=png&cookies=a%3D1%3Bb%3D2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flightKey(newRequest(tt.url)) == base; got != tt.same {
+				t.Errorf("flightKey() same = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"image"
+	_ "image/jpeg" // register JPEG for image.DecodeConfig
+	_ "image/png"  // register PNG for image.DecodeConfig
+	"regexp"
+)
+
+// ImageFormat is the format of the captured screenshot. The values match those accepted by OptionType.
+type ImageFormat string
+
+const (
+	FormatUnknown ImageFormat = ""
+	FormatJPEG    ImageFormat = "jpg"
+	FormatPNG     ImageFormat = "png"
+	FormatPDF     ImageFormat = "pdf"
+)
+
+var (
+	jpegMagic = []byte{0xFF, 0xD8, 0xFF}
+	pngMagic  = []byte("\x89PNG\r\n\x1a\n")
+	pdfMagic  = []byte("%PDF-")
+)
+
+// pdfPageRe matches page objects of PDF documents, but not the /Pages tree nodes.
+var pdfPageRe = regexp.MustCompile(`/Type\s*/Page\b`)
+
+// DetectFormat detects the screenshot format by the magic bytes of data.
+func DetectFormat(data []byte) ImageFormat {
+	switch {
+	case bytes.HasPrefix(data, jpegMagic):
+		return FormatJPEG
+	case bytes.HasPrefix(data, pngMagic):
+		return FormatPNG
+	case bytes.HasPrefix(data, pdfMagic):
+		return FormatPDF
+	}
+
+	return FormatUnknown
+}
+
+// imageSize returns pixel dimensions of the JPEG or PNG image.
+func imageSize(data []byte) (width, height int, ok bool) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return cfg.Width, cfg.Height, true
+}
+
+// pdfPageCount returns the number of pages of the PDF document. The count is based on page objects,
+// so pages stored in compressed object streams are not counted.
+func pdfPageCount(data []byte) int {
+	return len(pdfPageRe.FindAllIndex(data, -1))
+}
@@ -0,0 +1,299 @@
+package screenshotapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Manifest entry statuses.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// ManifestEntry is the line of the JSONL manifest: the job definition and its latest outcome.
+type ManifestEntry struct {
+	// ID identifies the job. If it's empty then the job is identified by URL and Filename
+	ID string `json:"id,omitempty"`
+
+	URL      string          `json:"url"`
+	Options  *CaptureOptions `json:"options,omitempty"`
+	Filename string          `json:"filename,omitempty"`
+
+	// Status is one of StatusPending, StatusSucceeded or StatusFailed
+	Status string `json:"status"`
+
+	// Output is the name of the written file
+	Output string `json:"output,omitempty"`
+
+	// SHA256 is the hex-encoded SHA-256 checksum of the screenshot
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Error is the error message of the failed attempt
+	Error string `json:"error,omitempty"`
+
+	// Attempts is the number of attempts made so far
+	Attempts int `json:"attempts"`
+
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// key returns the key identifying the job of the entry.
+func (e *ManifestEntry) key() string {
+	return jobKey(e.ID, e.URL, e.Filename)
+}
+
+// Job returns the job of the entry.
+func (e *ManifestEntry) Job() Job {
+	job := Job{
+		ID:       e.ID,
+		URL:      e.URL,
+		Filename: e.Filename,
+	}
+
+	if e.Options != nil {
+		job.Options = e.Options.Options()
+	}
+
+	return job
+}
+
+// jobCaptureOptions converts the job options to CaptureOptions for storing in the manifest.
+func jobCaptureOptions(job Job) (*CaptureOptions, error) {
+	v := url.Values{}
+	if err := setOptions(v, job.Options...); err != nil {
+		return nil, fmt.Errorf("job %s: %w", job.URL, err)
+	}
+
+	opts, err := ParseCaptureOptions(v)
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %w", job.URL, err)
+	}
+
+	return opts, nil
+}
+
+// jobKey returns the key identifying the job.
+func jobKey(id, url, filename string) string {
+	if id != "" {
+		return "id:" + id
+	}
+
+	return "url:" + url + "\x00" + filename
+}
+
+// Manifest is the JSONL log of batch jobs and outcomes. Every change is appended as a new line,
+// the latest line of a job wins. It allows resuming an interrupted batch: completed jobs are skipped
+// and failed ones are retried until the attempts budget is exhausted.
+type Manifest struct {
+	mu      sync.Mutex
+	f       *os.File
+	entries map[string]*ManifestEntry
+	order   []string
+}
+
+// OpenManifest opens or creates the manifest file and loads its entries.
+// The malformed last line left by an interrupted write is ignored.
+func OpenManifest(path string) (*Manifest, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, DefaultFilePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		f:       f,
+		entries: map[string]*ManifestEntry{},
+	}
+
+	size, complete, err := m.load(f)
+	if err == nil && !complete {
+		// drop the incomplete last line, so appended entries start on a new line
+		if err = f.Truncate(size); err == nil && size > 0 {
+			_, err = f.Write([]byte{'\n'})
+		}
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("cannot load manifest %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// load reads the manifest entries. It returns the size of the valid part of the file
+// and whether the file is empty or ends with a newline.
+func (m *Manifest) load(r io.Reader) (size int64, complete bool, err error) {
+	br := bufio.NewReader(r)
+
+	for line := 1; ; line++ {
+		raw, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return 0, false, err
+		}
+
+		if trimmed := bytes.TrimSpace(raw); len(trimmed) != 0 {
+			var entry ManifestEntry
+			if jerr := json.Unmarshal(trimmed, &entry); jerr != nil {
+				if err == io.EOF {
+					// the incomplete last line
+					return size, false, nil
+				}
+				return 0, false, fmt.Errorf("line %d: %w", line, jerr)
+			}
+			m.set(&entry)
+		}
+
+		size += int64(len(raw))
+
+		if err == io.EOF {
+			return size, len(raw) == 0, nil
+		}
+	}
+}
+
+// set stores the entry in memory.
+func (m *Manifest) set(entry *ManifestEntry) {
+	key := entry.key()
+	if _, ok := m.entries[key]; !ok {
+		m.order = append(m.order, key)
+	}
+	m.entries[key] = entry
+}
+
+// append writes the entry to the file and stores it in memory.
+func (m *Manifest) append(entry *ManifestEntry) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err = m.f.Write(append(raw, '\n')); err != nil {
+		return err
+	}
+
+	m.set(entry)
+
+	return nil
+}
+
+// Add appends pending entries for the jobs which are not in the manifest yet,
+// so adding the same list on every run is safe.
+func (m *Manifest) Add(jobs ...Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range jobs {
+		if _, ok := m.entries[jobKey(job.ID, job.URL, job.Filename)]; ok {
+			continue
+		}
+
+		opts, err := jobCaptureOptions(job)
+		if err != nil {
+			return err
+		}
+
+		entry := &ManifestEntry{
+			ID:        job.ID,
+			URL:       job.URL,
+			Options:   opts,
+			Filename:  job.Filename,
+			Status:    StatusPending,
+			Timestamp: time.Now().UTC(),
+		}
+		if err = m.append(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Pending returns the jobs which are not succeeded and have made less than maxAttempts attempts.
+// If maxAttempts is zero then failed jobs are always retried.
+func (m *Manifest) Pending(maxAttempts int) []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var jobs []Job
+
+	for _, key := range m.order {
+		entry := m.entries[key]
+		if entry.Status == StatusSucceeded || (maxAttempts > 0 && entry.Attempts >= maxAttempts) {
+			continue
+		}
+		jobs = append(jobs, entry.Job())
+	}
+
+	return jobs
+}
+
+// Entries returns the latest entries of all jobs in the order they were added.
+func (m *Manifest) Entries() []ManifestEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := make([]ManifestEntry, 0, len(m.order))
+	for _, key := range m.order {
+		entries = append(entries, *m.entries[key])
+	}
+
+	return entries
+}
+
+// Record appends the outcome of the job.
+func (m *Manifest) Record(res JobResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := jobKey(res.Job.ID, res.Job.URL, res.Job.Filename)
+
+	entry := ManifestEntry{
+		ID:       res.Job.ID,
+		URL:      res.Job.URL,
+		Filename: res.Job.Filename,
+		Status:   StatusSucceeded,
+		Output:   res.Filename,
+	}
+
+	if prev, ok := m.entries[key]; ok {
+		entry.Options = prev.Options
+		entry.Attempts = prev.Attempts
+	} else if opts, err := jobCaptureOptions(res.Job); err == nil {
+		entry.Options = opts
+	}
+
+	entry.Attempts++
+	entry.Timestamp = time.Now().UTC()
+
+	if res.Result != nil {
+		entry.SHA256 = res.Result.SHA256
+	}
+
+	if res.Err != nil {
+		entry.Status = StatusFailed
+		entry.Error = res.Err.Error()
+	}
+
+	return m.append(&entry)
+}
+
+// Close flushes and closes the manifest file.
+func (m *Manifest) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.f.Sync(); err != nil {
+		_ = m.f.Close()
+		return err
+	}
+
+	return m.f.Close()
+}
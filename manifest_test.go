@@ -0,0 +1,93 @@
+package screenshotapi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestBatchResume tests resuming the batch from the manifest.
+func TestBatchResume(t *testing.T) {
+	api, server := newBatchAPI()
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.jsonl")
+
+	jobs := []Job{
+		{URL: "a.com", Filename: filepath.Join(dir, "a.png"), Options: []Option{OptionType("png"), OptionWidth(300)}},
+		{ID: "b", URL: "broken.com"},
+		{URL: "c.com"},
+	}
+
+	run := func(wantTotal, wantSucceeded int) {
+		t.Helper()
+
+		m, err := OpenManifest(path)
+		if err != nil {
+			t.Fatalf("OpenManifest() error = %v", err)
+		}
+		defer m.Close()
+
+		if err = m.Add(jobs...); err != nil {
+			t.Fatalf("Manifest.Add() error = %v", err)
+		}
+
+		summary, err := NewBatch(api, BatchParams{Manifest: m, MaxJobAttempts: 2}).Resume(context.Background())
+		if err != nil {
+			t.Fatalf("Batch.Resume() error = %v", err)
+		}
+		if summary.Total != wantTotal || summary.Succeeded != wantSucceeded {
+			t.Errorf("Batch.Resume() = %+v, want total %d, succeeded %d", summary, wantTotal, wantSucceeded)
+		}
+	}
+
+	run(3, 2)
+	run(1, 0)
+	run(0, 0)
+
+	// simulate the write interrupted by a crash
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"url":"c.com","sta`)
+	_ = f.Close()
+
+	m, err := OpenManifest(path)
+	if err != nil {
+		t.Fatalf("OpenManifest() error = %v", err)
+	}
+
+	if err = m.Add(Job{URL: "d.com"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = m.Close()
+
+	m, err = OpenManifest(path)
+	if err != nil {
+		t.Fatalf("OpenManifest() error = %v", err)
+	}
+	defer m.Close()
+
+	entries := m.Entries()
+	if len(entries) != 4 {
+		t.Fatalf("Manifest.Entries() = %d entries, want 4", len(entries))
+	}
+
+	a := entries[0]
+	if a.Status != StatusSucceeded || a.Attempts != 1 || a.SHA256 == "" || a.Output != jobs[0].Filename ||
+		a.Options == nil || a.Options.Width != 300 || a.Options.Type != "png" {
+		t.Errorf("entry a = %+v", a)
+	}
+
+	b := entries[1]
+	if b.Status != StatusFailed || b.Attempts != 2 || b.Error == "" {
+		t.Errorf("entry b = %+v", b)
+	}
+
+	if d := entries[3]; d.Status != StatusPending || d.URL != "d.com" {
+		t.Errorf("entry d = %+v", d)
+	}
+}
@@ -2,6 +2,7 @@ package screenshotapi
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -9,18 +10,37 @@ import (
 type Cookies map[string]string
 
 // toString converts Cookies map to string in the following format: name1=value1;name2=value2.
+// Cookies are sorted by name, so the same map always produces the same string.
 func (c Cookies) toString() string {
 	str := make([]string, 0, len(c))
 	for key, value := range c {
 		str = append(str, key+"="+value)
 	}
+	sort.Strings(str)
 	return strings.Join(str, ";")
 }
 
+// parseCookies parses the string in the following format: name1=value1;name2=value2.
+func parseCookies(s string) Cookies {
+	c := Cookies{}
+	for _, pair := range strings.Split(s, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 {
+			c[kv[0]] = kv[1]
+		} else {
+			c[kv[0]] = ""
+		}
+	}
+	return c
+}
+
 // ErrorMessage is the error message.
 type ErrorMessage struct {
-	Code    int    `json:"code"`
-	Message string `json:"messages"`
+	Code    int    `json:"code" xml:"code"`
+	Message string `json:"messages" xml:"messages"`
 }
 
 // Error returns error message as a string.
@@ -33,6 +33,8 @@ var _ = []Option{
 	OptionTouchScreen(true),
 	OptionLandscape(true),
 	OptionFailOnHostnameChange(true),
+	OptionDevice("iphone-14"),
+	OptionDecodeDataURI(true),
 }
 
 const (
@@ -48,6 +50,7 @@ const (
 	MinTimeout    = 1000
 	MaxTimeout    = 30000
 	DefaultWidth  = 800
+	DefaultHeight = 600
 )
 
 var (
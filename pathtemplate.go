@@ -0,0 +1,197 @@
+package screenshotapi
+
+import (
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// PathData is the data available to path templates. All string fields are sanitised,
+// so they are safe to use as path segments.
+type PathData struct {
+	// ID is the batch job ID, or empty
+	ID string
+
+	// Host is the host of the target URL without the port, e.g. example.com
+	Host string
+
+	// Path is the path of the target URL without leading and trailing slashes, or "index" if it's empty
+	Path string
+
+	// Date and Time are the capture time formatted as 2006-01-02 and 150405
+	Date string
+	Time string
+
+	// Timestamp is the capture time for custom layouts, e.g. {{.Timestamp.Format "2006/01"}}
+	Timestamp time.Time
+
+	// Width and Height are the requested viewport size, DefaultWidth and DefaultHeight if not set
+	Width  int
+	Height int
+
+	// Type is the requested image type, jpg if not set
+	Type string
+
+	// Ext is the file extension of Type
+	Ext string
+
+	// Hash is the short hash of the target URL and the options identifying the capture
+	Hash string
+}
+
+// NewPathData creates the template data for capturing the URL with the options at the specified time.
+func NewPathData(targetURL string, at time.Time, opts ...Option) (PathData, error) {
+	v := url.Values{}
+	if err := setOptions(v, opts...); err != nil {
+		return PathData{}, err
+	}
+	v.Set("url", targetURL)
+
+	width, height, err := viewportSize(v)
+	if err != nil {
+		return PathData{}, err
+	}
+
+	imageType := v.Get("type")
+	if imageType == "" {
+		imageType = string(FormatJPEG)
+	}
+
+	data := PathData{
+		Host:      "unknown",
+		Path:      "index",
+		Date:      at.Format("2006-01-02"),
+		Time:      at.Format("150405"),
+		Timestamp: at,
+		Width:     width,
+		Height:    height,
+		Type:      sanitizeSegment(imageType),
+		Ext:       sanitizeSegment(imageType),
+		Hash:      cacheKey(v)[:16],
+	}
+
+	u, err := url.Parse(canonicalURL(targetURL))
+	if err == nil && u.Host == "" && u.Scheme == "" {
+		// URLs without a scheme are parsed as a path
+		u, err = url.Parse("http://" + u.String())
+	}
+	if err == nil {
+		if host := sanitizeSegment(u.Hostname()); host != "" {
+			data.Host = host
+		}
+		if p := sanitizePath(path.Clean("/" + u.Path)); p != "" {
+			data.Path = p
+		}
+	}
+
+	return data, nil
+}
+
+// viewportSize returns the requested width and height with defaults.
+func viewportSize(v url.Values) (width, height int, err error) {
+	if width, err = parseIntValue(v, "width"); err != nil {
+		return 0, 0, err
+	}
+	if height, err = parseIntValue(v, "height"); err != nil {
+		return 0, 0, err
+	}
+
+	if width == 0 {
+		width = DefaultWidth
+	}
+	if height == 0 {
+		height = DefaultHeight
+	}
+
+	return width, height, nil
+}
+
+// PathTemplate is the output path template, e.g. {{.Host}}/{{.Date}}/{{.Width}}x{{.Height}}.{{.Ext}}.
+// See PathData for the available fields.
+type PathTemplate struct {
+	text string
+	tmpl *template.Template
+}
+
+// ParsePathTemplate parses the path template. Unknown fields are reported immediately.
+func ParsePathTemplate(text string) (*PathTemplate, error) {
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, &ArgError{"template", err.Error()}
+	}
+
+	t := &PathTemplate{text: text, tmpl: tmpl}
+
+	// fields may be empty in the sample data, so only the execution errors are checked
+	if err = tmpl.Execute(io.Discard, PathData{Timestamp: time.Now()}); err != nil {
+		return nil, &ArgError{"template", err.Error()}
+	}
+
+	return t, nil
+}
+
+// String returns the template text.
+func (t *PathTemplate) String() string {
+	return t.text
+}
+
+// Execute expands the template. The result is cleaned, so it never contains . and .. segments
+// produced by template fields. The expansion which doesn't name a file, e.g. the empty one or the one
+// ending with a slash, is an error.
+func (t *PathTemplate) Execute(data PathData) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	p := path.Clean(b.String())
+	if base := path.Base(p); base == "." || base == ".." || base == "/" || strings.HasSuffix(b.String(), "/") {
+		return "", &ArgError{"template", "expands to " + strconv.Quote(b.String()) + " which is not a file name"}
+	}
+
+	return p, nil
+}
+
+// Expand expands the template for capturing the URL with the options at the specified time.
+func (t *PathTemplate) Expand(targetURL string, at time.Time, opts ...Option) (string, error) {
+	data, err := NewPathData(targetURL, at, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Execute(data)
+}
+
+// sanitizePath sanitises every segment of the slash-separated path and drops empty ones.
+func sanitizePath(p string) string {
+	var segments []string
+	for _, segment := range strings.Split(p, "/") {
+		if s := sanitizeSegment(segment); s != "" {
+			segments = append(segments, s)
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// sanitizeSegment replaces characters which are not safe in file names with underscores.
+// The result is never . or ..
+func sanitizeSegment(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+
+	if strings.Trim(s, ".") == "" && s != "" {
+		return strings.Repeat("_", len(s))
+	}
+
+	return s
+}
@@ -0,0 +1,185 @@
+package screenshotapi
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestPathTemplate tests expanding of path templates.
+func TestPathTemplate(t *testing.T) {
+	at := time.Date(2022, 11, 3, 14, 5, 9, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		url      string
+		opts     []Option
+		want     string
+	}{
+		{
+			name:     "defaults",
+			template: "{{.Host}}/{{.Date}}/{{.Width}}x{{.Height}}.{{.Ext}}",
+			url:      "whoisxmlapi.com",
+			want:     "whoisxmlapi.com/2022-11-03/800x600.jpg",
+		},
+		{
+			name:     "options",
+			template: "{{.Host}}/{{.Date}}/{{.Width}}x{{.Height}}.{{.Ext}}",
+			url:      "https://WhoisXMLAPI.com:8443/",
+			opts:     []Option{OptionType("png"), OptionWidth(1280), OptionHeight(720)},
+			want:     "whoisxmlapi.com/2022-11-03/1280x720.png",
+		},
+		{
+			name:     "device",
+			template: "{{.Host}}-{{.Width}}x{{.Height}}.{{.Ext}}",
+			url:      "example.com",
+			opts:     []Option{OptionDevice("iphone-14"), OptionType("pdf")},
+			want:     "example.com-390x844.pdf",
+		},
+		{
+			name:     "path and time",
+			template: "out/{{.Host}}/{{.Path}}/{{.Timestamp.Format \"2006/01\"}}/{{.Time}}.{{.Ext}}",
+			url:      "http://example.com/docs/intro page?q=1#top",
+			want:     "out/example.com/docs/intro_page/2022/11/140509.jpg",
+		},
+		{
+			name:     "empty path",
+			template: "{{.Host}}/{{.Path}}.{{.Ext}}",
+			url:      "https://example.com",
+			want:     "example.com/index.jpg",
+		},
+		{
+			name:     "traversal",
+			template: "out/{{.Path}}.{{.Ext}}",
+			url:      "http://example.com/../../../etc/passwd",
+			want:     "out/etc/passwd.jpg",
+		},
+		{
+			name:     "unsafe host",
+			template: "out/{{.Host}}.{{.Ext}}",
+			url:      "http://[::1]:8080/",
+			want:     "out/__1.jpg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParsePathTemplate(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tmpl.Expand(tt.url, at, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Expand() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPathTemplateEmpty tests that expansions which don't name a file are rejected.
+func TestPathTemplateEmpty(t *testing.T) {
+	for _, text := range []string{"{{.ID}}", "out/{{.ID}}/", "/{{.ID}}", "{{.ID}}/.."} {
+		tmpl, err := ParsePathTemplate(text)
+		if err != nil {
+			t.Fatalf("ParsePathTemplate(%q) error = %v", text, err)
+		}
+
+		if got, err := tmpl.Execute(PathData{}); !errors.As(err, new(*ArgError)) {
+			t.Errorf("Execute(%q) = %q, %v, want *ArgError", text, got, err)
+		}
+	}
+}
+
+// TestParsePathTemplate tests parsing errors.
+func TestParsePathTemplate(t *testing.T) {
+	for _, text := range []string{"{{.Host", "{{.Unknown}}.png"} {
+		if _, err := ParsePathTemplate(text); !errors.As(err, new(*ArgError)) {
+			t.Errorf("ParsePathTemplate(%q) error = %v, want *ArgError", text, err)
+		}
+	}
+}
+
+// TestSanitizeSegment tests the sanitizeSegment function.
+func TestSanitizeSegment(t *testing.T) {
+	tests := map[string]string{
+		"example.com": "example.com",
+		"a b/c\\d:e":  "a_b_c_d_e",
+		"..":          "__",
+		".":           "_",
+		"":            "",
+		"ünïcode":     "_n_code",
+	}
+
+	for in, want := range tests {
+		if got := sanitizeSegment(in); got != want {
+			t.Errorf("sanitizeSegment(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestPathTemplateGetAndBatch tests path templates with GetTemplate and Batch.
+func TestPathTemplateGetAndBatch(t *testing.T) {
+	api, server := newBatchAPI()
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	tmpl, err := ParsePathTemplate(filepath.ToSlash(dir) + "/get/{{.Host}}-{{.Width}}.{{.Ext}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := api.GetTemplate(context.Background(), "a.com", tmpl, FileParams{CreateDirs: true}, OptionType("png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "get", "a.com-800.png"); name != want {
+		t.Errorf("GetTemplate() = %s, want %s", name, want)
+	}
+	if _, err = os.Stat(name); err != nil {
+		t.Error(err)
+	}
+
+	// the filename is never treated as a template by Get
+	literal := filepath.Join(dir, "{{.Host}}.png")
+	if err = api.Get(context.Background(), "a.com", literal); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(literal); err != nil {
+		t.Error(err)
+	}
+
+	tmpl, err = ParsePathTemplate(filepath.ToSlash(dir) + "/batch/{{.ID}}/{{.Host}}.{{.Ext}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := NewBatch(api, BatchParams{PathTemplate: tmpl, FileParams: FileParams{CreateDirs: true}})
+
+	summary, err := batch.Run(context.Background(), []Job{
+		{ID: "1", URL: "b.com", Options: []Option{OptionType("png")}},
+		{ID: "../2", URL: "c.com"},
+		{URL: "d.com", Filename: filepath.Join(dir, "explicit.png")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Succeeded != 3 {
+		t.Fatalf("Succeeded = %d, want 3", summary.Succeeded)
+	}
+
+	for _, name := range []string{"batch/1/b.com.png", "batch/.._2/c.com.jpg", "explicit.png"} {
+		if _, err = os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Error(err)
+		}
+	}
+}
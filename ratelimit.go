@@ -0,0 +1,101 @@
+package screenshotapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is the token bucket limiting the number of requests per second.
+type rateLimiter struct {
+	mu sync.Mutex
+
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates the token bucket which is initially full.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns the time to wait before it becomes available.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns the reserved token to the bucket.
+func (l *rateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens++
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// wait blocks until a token is available or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := sleep(ctx, l.reserve()); err != nil {
+		l.cancel()
+		return err
+	}
+
+	return nil
+}
+
+// acquire waits for the rate limiter and a free in-flight slot. The returned function releases the slot.
+func (c *Client) acquire(ctx context.Context) (release func(), err error) {
+	if c.limiter != nil {
+		if err = c.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.inFlight == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case c.inFlight <- struct{}{}:
+		return func() { <-c.inFlight }, nil
+	case <-ctx.Done():
+		// the request is not made, so the token goes back to the bucket
+		if c.limiter != nil {
+			c.limiter.cancel()
+		}
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,111 @@
+package screenshotapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRateLimiter tests the token bucket.
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(100, 2)
+
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := l.wait(ctx); err != nil {
+			t.Fatalf("wait() error = %v", err)
+		}
+	}
+	// 2 tokens are available at once, the next 2 take 10ms each.
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("wait() took %v, expected at least 15ms", elapsed)
+	}
+
+	l = newRateLimiter(0.001, 1)
+	_ = l.wait(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	if err := l.wait(ctx); err == nil {
+		t.Errorf("wait() error = nil, want context error")
+	}
+}
+
+// TestMaxConcurrentRequests tests that the client never exceeds the number of requests in flight.
+func TestMaxConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		_, _ = w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	apiURL, _ := url.Parse(server.URL)
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:            server.Client(),
+		ScreenshotAPIBaseURL:  apiURL,
+		MaxConcurrentRequests: 2,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := api.GetRaw(context.Background(), "whoisxmlapi.com"); err != nil {
+				t.Errorf("GetRaw() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Errorf("max requests in flight = %d, want <= 2", maxInFlight)
+	}
+}
+
+// TestAcquireCanceled tests that the rate limit token is returned if the context is done
+// while waiting for a free in-flight slot.
+func TestAcquireCanceled(t *testing.T) {
+	client := NewClient(apiKey, ClientParams{RateLimit: 0.001, MaxConcurrentRequests: 1})
+
+	// the only slot is busy
+	client.inFlight <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.acquire(ctx); err == nil {
+		t.Fatal("acquire() error = nil, want context error")
+	}
+
+	<-client.inFlight
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	release, err := client.acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire() error = %v, want the returned token", err)
+	}
+	release()
+}
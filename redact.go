@@ -0,0 +1,64 @@
+package screenshotapi
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// redacted replaces the API key in URLs and headers.
+const redacted = "REDACTED"
+
+// redactURL returns the copy of u with the apiKey query parameter redacted.
+func redactURL(u *url.URL) *url.URL {
+	if u == nil {
+		return nil
+	}
+
+	r := *u
+
+	q := r.Query()
+	if _, ok := q["apiKey"]; ok {
+		q.Set("apiKey", redacted)
+		r.RawQuery = q.Encode()
+	}
+
+	return &r
+}
+
+// redactURLString returns rawURL with the apiKey query parameter redacted.
+func redactURLString(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	return redactURL(u).String()
+}
+
+// redactRequest returns the shallow copy of req with the API key redacted from the URL and headers.
+func redactRequest(req *http.Request, apiKeyHeader string) *http.Request {
+	if req == nil {
+		return nil
+	}
+
+	r := *req
+	r.URL = redactURL(req.URL)
+
+	if apiKeyHeader != "" && req.Header.Get(apiKeyHeader) != "" {
+		r.Header = req.Header.Clone()
+		r.Header.Set(apiKeyHeader, redacted)
+	}
+
+	return &r
+}
+
+// redactError redacts the API key from the URL of the *url.Error returned by http.Client.
+func redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactURLString(urlErr.URL)
+	}
+
+	return err
+}
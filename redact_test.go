@@ -0,0 +1,71 @@
+package screenshotapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestRedactAPIKey tests that the API key never appears in errors and responses.
+func TestRedactAPIKey(t *testing.T) {
+	server := dummyServer("", "", `{"code":499,"messages":"Test error message."}`)
+
+	api := newAPI(server, pathScreenshotAPIResponseError)
+
+	_, err := api.GetRaw(context.Background(), "whoisxmlapi.com")
+
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) {
+		t.Fatalf("ScreenshotAPI.GetRaw() error = %v, want *ErrorResponse", err)
+	}
+
+	if u := errResp.Response.Request.URL.String(); strings.Contains(u, apiKey) || !strings.Contains(u, redacted) {
+		t.Errorf("ErrorResponse.Response.Request.URL = %s, want redacted apiKey", u)
+	}
+
+	server.Close()
+
+	_, err = api.GetRaw(context.Background(), "whoisxmlapi.com")
+	if err == nil || strings.Contains(err.Error(), apiKey) {
+		t.Errorf("ScreenshotAPI.GetRaw() error = %v, want error without apiKey", err)
+	}
+
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Errorf("ScreenshotAPI.GetRaw() error = %v, want *url.Error", err)
+	}
+}
+
+// TestAPIKeyHeader tests sending the API key in the header.
+func TestAPIKeyHeader(t *testing.T) {
+	const header = "X-Authentication-Token"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get(header) != apiKey || req.URL.Query().Get("apiKey") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	apiURL, _ := url.Parse(server.URL)
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+		APIKeyHeader:         header,
+	})
+
+	resp, err := api.GetRaw(context.Background(), "whoisxmlapi.com")
+	if err != nil {
+		t.Fatalf("ScreenshotAPI.GetRaw() error = %v", err)
+	}
+
+	if got := resp.Request.Header.Get(header); got != redacted {
+		t.Errorf("Response.Request.Header = %s, want %s", got, redacted)
+	}
+}
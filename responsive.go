@@ -0,0 +1,159 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"sync"
+)
+
+// contactSheetGap is the gap between screenshots on the contact sheet in pixels.
+const contactSheetGap = 20
+
+// Viewport is the breakpoint for responsive capture.
+type Viewport struct {
+	// Name identifies the viewport in results, e.g. mobile
+	Name string
+
+	// Width and Height are the viewport size in pixels. Zero values are not sent
+	Width  int
+	Height int
+
+	// Options are the additional options for this viewport, e.g. OptionDevice. Width and Height override them
+	Options []Option
+}
+
+// DefaultViewports are the common mobile, tablet and desktop breakpoints.
+var DefaultViewports = []Viewport{
+	{Name: "mobile", Options: []Option{OptionDevice("iphone-14")}},
+	{Name: "tablet", Options: []Option{OptionDevice("ipad")}},
+	{Name: "desktop", Options: []Option{OptionDevice("desktop-1080p")}},
+}
+
+// ViewportResult is the outcome of capturing a single viewport.
+type ViewportResult struct {
+	Viewport Viewport
+
+	// Result is the captured screenshot, or nil if Err is not nil
+	Result *Result
+
+	// Err is the capture error
+	Err error
+}
+
+// ResponsiveResult is the outcome of CaptureResponsive in the order of the requested viewports.
+type ResponsiveResult struct {
+	Viewports []ViewportResult
+}
+
+// Err returns the first viewport error, or nil if all viewports are captured.
+func (r *ResponsiveResult) Err() error {
+	for _, v := range r.Viewports {
+		if v.Err != nil {
+			return fmt.Errorf("viewport %s: %w", v.Viewport.Name, v.Err)
+		}
+	}
+
+	return nil
+}
+
+// ContactSheet composes the captured JPEG and PNG screenshots side by side into a single PNG image.
+// Failed viewports and screenshots which can't be decoded, such as PDF documents or base64 data URIs,
+// are skipped.
+func (r *ResponsiveResult) ContactSheet() ([]byte, error) {
+	var images []image.Image
+
+	width, height := 0, 0
+
+	for _, v := range r.Viewports {
+		if v.Err != nil || v.Result == nil {
+			continue
+		}
+
+		img, _, err := image.Decode(bytes.NewReader(v.Result.Body))
+		if err != nil {
+			continue
+		}
+
+		images = append(images, img)
+
+		if len(images) > 1 {
+			width += contactSheetGap
+		}
+		width += img.Bounds().Dx()
+		if h := img.Bounds().Dy(); h > height {
+			height = h
+		}
+	}
+
+	if len(images) == 0 {
+		return nil, errors.New("no captured JPEG or PNG screenshots")
+	}
+
+	sheet := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(sheet, sheet.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+
+	x := 0
+	for _, img := range images {
+		b := img.Bounds()
+		draw.Draw(sheet, image.Rect(x, 0, x+b.Dx(), b.Dy()), img, b.Min, draw.Over)
+		x += b.Dx() + contactSheetGap
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, sheet); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// CaptureResponsive captures the URL at every viewport concurrently. Options are applied to every viewport
+// and overridden by the viewport options, then by the viewport size. Per-viewport errors are reported in the result.
+func (service screenshotAPIServiceOp) CaptureResponsive(
+	ctx context.Context,
+	url string,
+	viewports []Viewport,
+	opts ...Option,
+) (*ResponsiveResult, error) {
+	if url == "" {
+		return nil, &ArgError{"URL", "can not be empty"}
+	}
+	if len(viewports) == 0 {
+		return nil, &ArgError{"viewports", "can not be empty"}
+	}
+
+	result := &ResponsiveResult{Viewports: make([]ViewportResult, len(viewports))}
+
+	var wg sync.WaitGroup
+
+	for i, vp := range viewports {
+		vpOpts := make([]Option, 0, len(opts)+len(vp.Options)+2)
+		vpOpts = append(vpOpts, opts...)
+		vpOpts = append(vpOpts, vp.Options...)
+		// the explicit size goes last, so a device preset in the options doesn't override it
+		if vp.Width != 0 {
+			vpOpts = append(vpOpts, OptionWidth(vp.Width))
+		}
+		if vp.Height != 0 {
+			vpOpts = append(vpOpts, OptionHeight(vp.Height))
+		}
+
+		wg.Add(1)
+		go func(i int, vp Viewport, vpOpts []Option) {
+			defer wg.Done()
+
+			res, err := service.Capture(ctx, url, vpOpts...)
+			result.Viewports[i] = ViewportResult{Viewport: vp, Result: res, Err: err}
+		}(i, vp, vpOpts)
+	}
+
+	wg.Wait()
+
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+// TestScreenshotAPICaptureResponsive tests the CaptureResponsive function.
+func TestScreenshotAPICaptureResponsive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		width, _ := strconv.Atoi(req.URL.Query().Get("width"))
+		height, _ := strconv.Atoi(req.URL.Query().Get("height"))
+		if width == 500 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"code":422,"messages":"Hostname changed."}`))
+			return
+		}
+		_, _ = w.Write(pngImage(width, height))
+	}))
+	defer server.Close()
+
+	apiURL, _ := url.Parse(server.URL)
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+	})
+
+	viewports := []Viewport{
+		{Name: "mobile", Width: 300, Height: 600},
+		{Name: "broken", Width: 500, Height: 500},
+		{Name: "desktop", Options: []Option{OptionDevice("desktop-720p")}},
+		{Name: "sized device", Width: 400, Height: 800, Options: []Option{OptionDevice("desktop-720p")}},
+	}
+
+	res, err := api.CaptureResponsive(context.Background(), "whoisxmlapi.com", viewports, OptionType("png"))
+	if err != nil {
+		t.Fatalf("ScreenshotAPI.CaptureResponsive() error = %v", err)
+	}
+
+	if len(res.Viewports) != 4 {
+		t.Fatalf("ScreenshotAPI.CaptureResponsive() returned %d viewports, want 4", len(res.Viewports))
+	}
+	if r := res.Viewports[0].Result; r == nil || r.Width != 300 || r.Height != 600 {
+		t.Errorf("mobile viewport = %+v", res.Viewports[0])
+	}
+	if !errors.Is(res.Viewports[1].Err, ErrHostnameChanged) || !errors.Is(res.Err(), ErrHostnameChanged) {
+		t.Errorf("broken viewport error = %v", res.Viewports[1].Err)
+	}
+	if r := res.Viewports[2].Result; r == nil || r.Width != 1280 || r.Height != 720 {
+		t.Errorf("desktop viewport = %+v", res.Viewports[2])
+	}
+	if r := res.Viewports[3].Result; r == nil || r.Width != 400 || r.Height != 800 {
+		t.Errorf("sized device viewport = %+v", res.Viewports[3])
+	}
+
+	sheet, err := res.ContactSheet()
+	if err != nil {
+		t.Fatalf("ResponsiveResult.ContactSheet() error = %v", err)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(sheet))
+	if err != nil || format != "png" || cfg.Width != 300+contactSheetGap+1280+contactSheetGap+400 || cfg.Height != 800 {
+		t.Errorf("ResponsiveResult.ContactSheet() = %s %dx%d, %v", format, cfg.Width, cfg.Height, err)
+	}
+}
+
+// TestContactSheetSkipsDocuments tests that screenshots which aren't images are skipped on the contact sheet.
+func TestContactSheetSkipsDocuments(t *testing.T) {
+	res := &ResponsiveResult{Viewports: []ViewportResult{
+		{Viewport: Viewport{Name: "pdf"}, Result: &Result{Body: []byte("%PDF-1.4\n%%EOF")}},
+		{Viewport: Viewport{Name: "png"}, Result: &Result{Body: pngImage(200, 100)}},
+		{Viewport: Viewport{Name: "base64"}, Result: &Result{Body: []byte("data:image/png;base64,iVBORw0KGgo=")}},
+	}}
+
+	sheet, err := res.ContactSheet()
+	if err != nil {
+		t.Fatalf("ResponsiveResult.ContactSheet() error = %v", err)
+	}
+
+	cfg, err := png.DecodeConfig(bytes.NewReader(sheet))
+	if err != nil || cfg.Width != 200 || cfg.Height != 100 {
+		t.Errorf("ResponsiveResult.ContactSheet() = %dx%d, %v, want 200x100", cfg.Width, cfg.Height, err)
+	}
+
+	res.Viewports = res.Viewports[:1]
+	if _, err = res.ContactSheet(); err == nil {
+		t.Error("ResponsiveResult.ContactSheet() error = nil, want error without images")
+	}
+}
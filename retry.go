@@ -0,0 +1,222 @@
+package screenshotapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy describes how failed requests are retried.
+// The zero value makes exactly one attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	// Values less than 1 are treated as 1.
+	MaxAttempts int
+
+	// BaseBackoff is the delay before the second attempt. Every following delay is doubled.
+	BaseBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts including the one asked by the Retry-After header.
+	// Zero means no cap.
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction (0..1) of the delay which is randomly subtracted from it.
+	Jitter float64
+
+	// RetryableStatusCodes is the list of HTTP status codes which are retried.
+	RetryableStatusCodes []int
+
+	// RetryableError reports whether an error returned by Client.Do is retried.
+	// If it's nil then IsRetryableError is used.
+	RetryableError func(err error) bool
+}
+
+// DefaultRetryPolicy returns the recommended retry policy: up to 3 attempts on 429, 5xx and transport errors.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: 500 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+		Jitter:      0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// IsRetryableError reports whether err is a transient error: a timeout, a connection reset
+// or refusal, a prematurely closed response body, or a body failing validation.
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, ErrInvalidBody) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return false
+}
+
+// attempts returns the maximum number of attempts.
+func (p *RetryPolicy) attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+
+	return p.MaxAttempts
+}
+
+// shouldRetry reports whether the result of an attempt is retried.
+func (p *RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	if p == nil {
+		return false
+	}
+
+	if err != nil {
+		if p.RetryableError != nil {
+			return p.RetryableError(err)
+		}
+		return IsRetryableError(err)
+	}
+
+	if resp == nil {
+		return false
+	}
+
+	for _, code := range p.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff returns the delay before the next attempt. attempt is the number of the failed attempt starting with 1.
+// The Retry-After header of the response takes precedence if it asks to wait longer, but it's capped by
+// MaxBackoff too, so a server can't stall the client for longer than the policy allows.
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	delay := time.Duration(float64(p.BaseBackoff) * math.Pow(2, float64(attempt-1)))
+	if p.MaxBackoff > 0 && (delay > p.MaxBackoff || delay < 0) {
+		delay = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * math.Min(p.Jitter, 1) * float64(delay))
+	}
+
+	if resp != nil {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok && retryAfter > delay {
+			delay = retryAfter
+			if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+				delay = p.MaxBackoff
+			}
+		}
+	}
+
+	return delay
+}
+
+// parseRetryAfter parses the Retry-After header value given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// retry makes attempts according to the retry policy of the client. Every attempt waits for the rate
+// limiter and a free in-flight slot. The slot of the last attempt is returned to be released by the caller,
+// responses of retried attempts are passed to discard if it's not nil. If waiting for the next attempt fails,
+// the response and the error of the previous attempt are returned, or the context error if it succeeded.
+func (c *Client) retry(
+	ctx context.Context,
+	attempt func() (*http.Response, error),
+	discard func(*http.Response),
+) (resp *http.Response, release func(), err error) {
+	policy := c.retryPolicy
+	noop := func() {}
+
+	for n := 1; ; n++ {
+		slot, aerr := c.acquire(ctx)
+		if aerr != nil {
+			if err == nil {
+				err = aerr
+			}
+			return resp, noop, err
+		}
+
+		resp, err = attempt()
+
+		if n >= policy.attempts() || !policy.shouldRetry(resp, err) {
+			return resp, slot, err
+		}
+
+		if resp != nil && discard != nil {
+			discard(resp)
+		}
+		slot()
+
+		if serr := sleep(ctx, policy.backoff(n, resp)); serr != nil {
+			if err == nil {
+				err = serr
+			}
+			return resp, noop, err
+		}
+	}
+}
+
+// sleep waits for the specified duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,232 @@
+package screenshotapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer returns the server which responds with the specified status codes in turn and with 200 afterwards.
+func flakyServer(retryAfter string, codes ...int) (*httptest.Server, *int32) {
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if n <= len(codes) {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(codes[n-1])
+			_, _ = w.Write([]byte(`{"code":500,"messages":"Internal error."}`))
+			return
+		}
+		_, _ = w.Write([]byte("image"))
+	}))
+
+	return server, &calls
+}
+
+// TestRetryPolicy tests the retry loop of the request function.
+func TestRetryPolicy(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:          3,
+		BaseBackoff:          time.Millisecond,
+		MaxBackoff:           5 * time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+	}
+
+	tests := []struct {
+		name       string
+		policy     *RetryPolicy
+		codes      []int
+		wantCalls  int32
+		wantStatus int
+	}{
+		{
+			name:       "no policy",
+			policy:     nil,
+			codes:      []int{503},
+			wantCalls:  1,
+			wantStatus: 503,
+		},
+		{
+			name:       "recovered",
+			policy:     policy,
+			codes:      []int{503, 429},
+			wantCalls:  3,
+			wantStatus: 200,
+		},
+		{
+			name:       "attempts exhausted",
+			policy:     policy,
+			codes:      []int{503, 503, 503, 503},
+			wantCalls:  3,
+			wantStatus: 503,
+		},
+		{
+			name:       "not retryable status code",
+			policy:     policy,
+			codes:      []int{500},
+			wantCalls:  1,
+			wantStatus: 500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := flakyServer("", tt.codes...)
+			defer server.Close()
+
+			apiURL, _ := url.Parse(server.URL)
+			api := NewClient(apiKey, ClientParams{
+				HTTPClient:           server.Client(),
+				ScreenshotAPIBaseURL: apiURL,
+				RetryPolicy:          tt.policy,
+			})
+
+			resp, _ := api.GetRaw(context.Background(), "whoisxmlapi.com")
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if resp == nil || resp.StatusCode != tt.wantStatus {
+				t.Errorf("response = %v, want status %d", resp, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestRetryPolicyContext tests that waiting for the next attempt is interrupted by the context.
+func TestRetryPolicyContext(t *testing.T) {
+	server, calls := flakyServer("60", 429, 429)
+	defer server.Close()
+
+	apiURL, _ := url.Parse(server.URL)
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+		RetryPolicy:          DefaultRetryPolicy(),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := api.GetRaw(ctx, "whoisxmlapi.com")
+	if err == nil {
+		t.Errorf("GetRaw() error = nil, want context error")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Retry-After was not interrupted by the context")
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+// TestRetryAfterCap tests that the delay asked by the Retry-After header is capped by MaxBackoff.
+func TestRetryAfterCap(t *testing.T) {
+	server, calls := flakyServer("3600", 429)
+	defer server.Close()
+
+	apiURL, _ := url.Parse(server.URL)
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts:          2,
+			BaseBackoff:          time.Millisecond,
+			MaxBackoff:           10 * time.Millisecond,
+			RetryableStatusCodes: []int{http.StatusTooManyRequests},
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := api.GetRaw(ctx, "whoisxmlapi.com"); err != nil {
+		t.Fatalf("GetRaw() error = %v, want the capped Retry-After delay", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"3600"}}}
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   time.Duration
+	}{
+		{"capped", RetryPolicy{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second}, 10 * time.Second},
+		{"no cap", RetryPolicy{BaseBackoff: time.Second}, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.backoff(1, resp); got != tt.want {
+				t.Errorf("backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRetryLimiterError tests that the error of the last attempt is kept if waiting for the rate limiter
+// before the next attempt fails.
+func TestRetryLimiterError(t *testing.T) {
+	errAttempt := errors.New("connection reset")
+
+	client := NewClient(apiKey, ClientParams{
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts:    3,
+			RetryableError: func(err error) bool { return true },
+		},
+		RateLimit: 0.001,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var calls int
+
+	_, release, err := client.retry(ctx, func() (*http.Response, error) {
+		calls++
+		return nil, errAttempt
+	}, nil)
+	release()
+
+	if !errors.Is(err, errAttempt) {
+		t.Errorf("retry() error = %v, want %v", err, errAttempt)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+// TestParseRetryAfter tests the parseRetryAfter function.
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{"Sat, 01 Jan 2022 00:00:10 GMT", 10 * time.Second, true},
+		{"Fri, 31 Dec 2021 00:00:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
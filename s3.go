@@ -0,0 +1,303 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// s3Service is the service name in the SigV4 credential scope.
+	s3Service = "s3"
+
+	// sigV4Algorithm is the signing algorithm identifier.
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+	// amzDateFormat is the layout of the X-Amz-Date header.
+	amzDateFormat = "20060102T150405Z"
+)
+
+// S3Params is used to create S3Sink.
+type S3Params struct {
+	// Endpoint is the S3-compatible service endpoint, e.g. https://s3.us-east-1.amazonaws.com
+	Endpoint *url.URL
+
+	// Region is the bucket region used for signing, e.g. us-east-1
+	Region string
+
+	// Bucket is the bucket name
+	Bucket string
+
+	// Prefix is prepended to every key, e.g. screenshots/
+	Prefix string
+
+	// AccessKeyID and SecretAccessKey are the credentials used to sign requests
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken is the temporary security token, if any
+	SessionToken string
+
+	// PathStyle if true, the bucket is addressed as a path (endpoint/bucket/key) instead of
+	// a subdomain (bucket.endpoint/key). Most self-hosted S3-compatible services require it
+	PathStyle bool
+
+	// HTTPClient is the client used to make requests
+	// If it's nil then http.DefaultClient is used
+	HTTPClient *http.Client
+}
+
+// S3Error is the error response of the S3-compatible service.
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+// Error returns error message as a string.
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("S3 request failed with status code: %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("S3 request failed with status code: %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// S3Sink stores objects in the bucket of the S3-compatible service. Requests are signed with
+// AWS Signature Version 4. Metadata fields are stored as x-amz-meta-* headers.
+type S3Sink struct {
+	params S3Params
+	client *http.Client
+
+	// now returns the signing time, it's replaced in tests
+	now func() time.Time
+}
+
+// NewS3Sink creates S3Sink.
+func NewS3Sink(params S3Params) (*S3Sink, error) {
+	if params.Endpoint == nil {
+		return nil, &ArgError{"Endpoint", "can not be nil"}
+	}
+	if params.Bucket == "" {
+		return nil, &ArgError{"Bucket", "can not be empty"}
+	}
+	if params.Region == "" {
+		return nil, &ArgError{"Region", "can not be empty"}
+	}
+
+	client := http.DefaultClient
+	if params.HTTPClient != nil {
+		client = params.HTTPClient
+	}
+
+	return &S3Sink{params: params, client: client, now: time.Now}, nil
+}
+
+// objectURL returns the URL of the object with the key.
+func (s *S3Sink) objectURL(key string) *url.URL {
+	u := *s.params.Endpoint
+
+	objectPath := "/" + key
+	if s.params.PathStyle {
+		objectPath = "/" + s.params.Bucket + objectPath
+	} else {
+		u.Host = s.params.Bucket + "." + u.Host
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + objectPath
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = ""
+
+	return &u
+}
+
+// Put implements Sink. The object is read into memory to compute its checksum for signing.
+func (s *S3Sink) Put(ctx context.Context, key string, r io.Reader, meta SinkMetadata) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(s.params.Prefix+key).String(),
+		bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if meta.ContentType != "" {
+		req.Header.Set("Content-Type", meta.ContentType)
+	}
+	for k, v := range meta.Fields {
+		req.Header.Set("X-Amz-Meta-"+k, v)
+	}
+
+	sum := sha256.Sum256(data)
+	s.sign(req, hex.EncodeToString(sum[:]))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("cannot read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		s3Err := &S3Error{}
+		_ = xml.Unmarshal(body, s3Err)
+		s3Err.StatusCode = resp.StatusCode
+		return s3Err
+	}
+
+	return nil
+}
+
+// sign adds the AWS Signature Version 4 headers to the request. Host and all request headers
+// except User-Agent are signed.
+func (s *S3Sink) sign(req *http.Request, payloadHash string) {
+	t := s.now().UTC()
+	amzDate := t.Format(amzDateFormat)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.params.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.params.SessionToken)
+	}
+
+	req.Header.Set("Authorization", signV4(req, payloadHash, t,
+		s.params.Region, s3Service, s.params.AccessKeyID, s.params.SecretAccessKey))
+}
+
+// signV4 returns the Authorization header value of the request signed with AWS Signature Version 4.
+func signV4(req *http.Request, payloadHash string, t time.Time, region, service, accessKeyID, secret string) string {
+	headers := map[string]string{"host": req.Host}
+	if req.Host == "" {
+		headers["host"] = req.URL.Host
+	}
+
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "authorization" || name == "user-agent" {
+			continue
+		}
+
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := t.Format("20060102")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		t.Format(amzDateFormat),
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, accessKeyID, scope, signedHeaders, signature)
+}
+
+// canonicalQuery returns the query string sorted and encoded for signing.
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	encoded := make(map[string][]string, len(query))
+	for name, values := range query {
+		n := uriEncode(name, true)
+		names = append(names, n)
+		for _, v := range values {
+			encoded[n] = append(encoded[n], uriEncode(v, true))
+		}
+		sort.Strings(encoded[n])
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		for _, v := range encoded[name] {
+			pairs = append(pairs, name+"="+v)
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything except unreserved characters as required by SigV4.
+// Slashes are kept unless encodeSlash is true.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+// hmacSHA256 returns HMAC-SHA256 of data.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+
+	return h.Sum(nil)
+}
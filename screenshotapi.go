@@ -4,21 +4,46 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 	"net/url"
-	"os"
-	"strconv"
+	"time"
 )
 
 // ScreenshotAPIService is an interface for Screenshot API.
 type ScreenshotAPIService interface {
 	// Get captures a screenshot to a file, or returns a parsed Screenshot API error.
+	// The file is written atomically according to ClientParams.FileParams.
 	Get(ctx context.Context, url string, filename string, opts ...Option) error
 
+	// GetFile captures a screenshot to a file written according to params, and returns the name
+	// of the written file, or a parsed Screenshot API error.
+	GetFile(ctx context.Context, url string, filename string, params FileParams, opts ...Option) (string, error)
+
+	// GetTemplate captures a screenshot to a file named by expanding the path template, and returns
+	// the name of the written file, or a parsed Screenshot API error.
+	GetTemplate(ctx context.Context, url string, tmpl *PathTemplate, params FileParams, opts ...Option) (string, error)
+
 	// GetRaw returns raw Screenshot API response as the Response struct with Body saved as a byte slice.
 	GetRaw(ctx context.Context, URL string, opts ...Option) (*Response, error)
+
+	// GetTo captures a screenshot and streams it to w, or returns a parsed Screenshot API error.
+	GetTo(ctx context.Context, url string, w io.Writer, opts ...Option) error
+
+	// GetReader returns the screenshot as a stream which must be closed by the caller,
+	// or a parsed Screenshot API error.
+	GetReader(ctx context.Context, url string, opts ...Option) (io.ReadCloser, *ResponseMeta, error)
+
+	// Capture captures a screenshot and returns it with metadata, or returns a parsed Screenshot API error.
+	Capture(ctx context.Context, url string, opts ...Option) (*Result, error)
+
+	// CaptureTo captures a screenshot and stores it in the sink under the key.
+	CaptureTo(ctx context.Context, url string, sink Sink, key string, opts ...Option) (*Result, error)
+
+	// CaptureResponsive captures the URL at every viewport concurrently and returns per-viewport results.
+	CaptureResponsive(ctx context.Context, url string, viewports []Viewport, opts ...Option) (*ResponsiveResult, error)
 }
 
 // Response is the http.Response wrapper with Body saved as a byte slice.
@@ -27,6 +52,15 @@ type Response struct {
 
 	// Body is the byte slice representation of http.Response Body
 	Body []byte
+
+	// MediaType is the MIME type declared by the data URI when it's decoded (see OptionDecodeDataURI)
+	MediaType string
+
+	// Cached is true if the response is served from ClientParams.Cache
+	Cached bool
+
+	// Shared is true if the response is the copy of the one made for a concurrent identical request
+	Shared bool
 }
 
 // screenshotAPIServiceOp is the type implementing the ScreenshotAPI interface.
@@ -38,12 +72,18 @@ type screenshotAPIServiceOp struct {
 var _ ScreenshotAPIService = &screenshotAPIServiceOp{}
 
 // newRequest creates the API request with default parameters and the specified apiKey.
+// The apiKey is sent in the query string or in the ClientParams.APIKeyHeader header.
 func (service screenshotAPIServiceOp) newRequest() (*http.Request, error) {
 	req, err := service.client.NewRequest(http.MethodGet, service.baseURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	if service.client.apiKeyHeader != "" {
+		req.Header.Set(service.client.apiKeyHeader, service.client.apiKey)
+		return req, nil
+	}
+
 	query := url.Values{}
 	query.Set("apiKey", service.client.apiKey)
 
@@ -52,138 +92,217 @@ func (service screenshotAPIServiceOp) newRequest() (*http.Request, error) {
 	return req, nil
 }
 
-// setOptions sets options provided as arguments.
+// setOptions sets options provided as arguments and reports all invalid options at once.
 func setOptions(v url.Values, opts ...Option) error {
-	for _, opt := range opts {
-		if opt == nil {
-			return &ArgError{"Option", "can not be nil"}
-		}
-		if err := opt(v); err != nil {
-			return err
-		}
-	}
+	errs, custom := applyOptions(v, false, opts...)
 
-	width, err := strconv.Atoi(v.Get("width"))
-	if err != nil {
-		width = 0
-	}
-	thumbWidth, err := strconv.Atoi(v.Get("thumbWidth"))
-	if err != nil {
-		thumbWidth = 0
-	}
-	if (width != 0 && thumbWidth > width) || (width == 0 && thumbWidth > DefaultWidth) {
-		return &ArgError{"thumbWidth", "must be between 50 and width param value"}
+	if err := thumbWidthRule(v); err != nil {
+		errs = append(errs, err)
 	}
 
-	return nil
+	return joinOptionErrors(errs, custom)
 }
 
-// request returns intermediate API response for further actions.
-func (service screenshotAPIServiceOp) request(ctx context.Context, url string, opts ...Option) (*Response, error) {
+// captureRequest creates the API request for capturing the specified URL with the options provided.
+// It also reports whether OptionDecodeDataURI is set, the option is handled by the client and isn't sent.
+func (service screenshotAPIServiceOp) captureRequest(url string, opts ...Option) (req *http.Request, decode bool, err error) {
 	if url == "" {
-		return nil, &ArgError{"URL", "can not be empty"}
+		return nil, false, &ArgError{"URL", "can not be empty"}
 	}
 
-	req, err := service.newRequest()
+	req, err = service.newRequest()
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	q := req.URL.Query()
 	q.Set("url", url)
 
 	if err = setOptions(q, opts...); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
+	decode = q.Get(paramDecodeDataURI) != ""
+	q.Del(paramDecodeDataURI)
+
 	req.URL.RawQuery = q.Encode()
 
-	var b bytes.Buffer
+	return req, decode, nil
+}
 
-	resp, err := service.client.Do(ctx, req, &b)
+// request returns intermediate API response for further actions. It serves the response from
+// the cache if the client has one, coalesces concurrent identical requests, and decodes the data URI
+// body if OptionDecodeDataURI is set.
+func (service screenshotAPIServiceOp) request(ctx context.Context, url string, opts ...Option) (*Response, error) {
+	req, decode, err := service.captureRequest(url, opts...)
 	if err != nil {
-		return &Response{
-			Response: resp,
-			Body:     b.Bytes(),
-		}, err
+		return nil, err
+	}
+
+	resp, err := service.cachedRequest(ctx, req)
+	if err != nil || !decode {
+		return resp, err
+	}
+
+	if err = decodeDataURI(resp); err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// cachedRequest serves the request from the cache if the client has one, otherwise sends it
+// coalescing concurrent identical requests, and caches the successful response.
+func (service screenshotAPIServiceOp) cachedRequest(ctx context.Context, req *http.Request) (*Response, error) {
+	cache := service.client.cache
+
+	var key string
+	if cache != nil {
+		key = cacheKey(req.URL.Query())
+		if resp := cache.get(key); resp != nil {
+			resp.Request = redactRequest(req, service.client.apiKeyHeader)
+			return resp, nil
+		}
+	}
+
+	// concurrent identical requests share a single upstream call
+	return service.client.flights.do(ctx, flightKey(req), func(ctx context.Context) (*Response, error) {
+		resp, err := service.fetch(ctx, req)
+		if cache != nil && err == nil && resp.StatusCode >= 200 && resp.StatusCode <= 299 &&
+			parseErrorMessage(resp.Body) == nil {
+			// the cache is best-effort, the response is returned even if it can't be stored
+			_ = cache.put(key, resp)
+		}
+
+		return resp, err
+	})
+}
+
+// fetch sends the request to the Screenshot API and retries it according to the retry policy.
+func (service screenshotAPIServiceOp) fetch(ctx context.Context, req *http.Request) (*Response, error) {
+	var b bytes.Buffer
+
+	resp, release, err := service.client.retry(ctx, func() (*http.Response, error) {
+		b.Reset()
+
+		resp, err := service.client.Do(ctx, req, &b)
+		if err == nil && service.client.validateBody {
+			err = validateResponse(req.URL.Query(), &Response{Response: resp, Body: b.Bytes()})
+		}
+
+		return resp, err
+	}, nil)
+	release()
+
+	if resp == nil {
+		return nil, err
 	}
 
 	return &Response{
 		Response: resp,
 		Body:     b.Bytes(),
-	}, nil
+	}, err
 }
 
-// apiResponse is used for parsing Screenshot API response as a model instance.
-type apiResponse struct {
-	ErrorMessage
-}
+// parseErrorMessage parses the Screenshot API error message in JSON or XML format.
+// It returns nil if raw is not an error message.
+func parseErrorMessage(raw []byte) *ErrorMessage {
+	var msg ErrorMessage
 
-// parse parses raw Screenshot API response.
-func parse(raw []byte) (*apiResponse, error) {
-	var response apiResponse
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return nil
+	}
 
-	err := json.NewDecoder(bytes.NewReader(raw)).Decode(&response)
-	if err != nil {
-		return nil, fmt.Errorf("cannot parse response: %w", err)
+	switch trimmed[0] {
+	case '{':
+		if json.Unmarshal(trimmed, &msg) != nil {
+			return nil
+		}
+	case '<':
+		if xml.Unmarshal(trimmed, &msg) != nil {
+			return nil
+		}
+	default:
+		return nil
 	}
 
-	return &response, nil
+	if msg.Code == 0 && msg.Message == "" {
+		return nil
+	}
+
+	return &msg
 }
 
 // Get captures a screenshot to a file, or returns a parsed Screenshot API error.
+// The file is written according to ClientParams.FileParams. Use GetFile to set them per call
+// or to get the name picked for OverwriteUnique.
 func (service screenshotAPIServiceOp) Get(
 	ctx context.Context,
 	url string,
 	filename string,
 	opts ...Option,
 ) (err error) {
+	_, err = service.GetFile(ctx, url, filename, service.client.fileParams, opts...)
+
+	return err
+}
+
+// GetFile captures a screenshot to a file written according to params, and returns the name
+// of the written file, or a parsed Screenshot API error. The name differs from filename for OverwriteUnique.
+func (service screenshotAPIServiceOp) GetFile(
+	ctx context.Context,
+	url string,
+	filename string,
+	params FileParams,
+	opts ...Option,
+) (string, error) {
 	if filename == "" {
-		return &ArgError{"filename", "can not be empty"}
+		return "", &ArgError{"filename", "can not be empty"}
 	}
 
-	optsJSON := make([]Option, 0, len(opts)+2)
-	optsJSON = append(optsJSON, opts...)
-	optsJSON = append(optsJSON,
-		OptionErrorsOutputFormat("JSON"),
-		OptionImageOutputFormat("image"),
-	)
+	optsImage := make([]Option, 0, len(opts)+2)
+	optsImage = append(optsImage, OptionErrorsOutputFormat("JSON"))
+	optsImage = append(optsImage, opts...)
+	optsImage = append(optsImage, OptionImageOutputFormat("image"))
 
-	resp, err := service.request(ctx, url, optsJSON...)
+	resp, err := service.request(ctx, url, optsImage...)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	screenshotAPIResp, err := parse(resp.Body)
-	if err == nil && (screenshotAPIResp.Message != "" || screenshotAPIResp.Code != 0) {
-		return &ErrorMessage{
-			Code:    screenshotAPIResp.Code,
-			Message: screenshotAPIResp.Message,
-		}
+	// non-2xx responses carry the body, headers and request ID, and unwrap to *ErrorMessage
+	if respErr := checkResponse(resp.Response, resp.Body); respErr != nil {
+		return "", respErr
 	}
 
-	if respErr := checkResponse(resp.Response); respErr != nil {
-		return respErr
+	if msg := parseErrorMessage(resp.Body); msg != nil {
+		return "", msg
 	}
 
-	f, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
+	return WriteFile(filename, bytes.NewReader(resp.Body), params)
+}
 
-	defer func(f *os.File) {
-		err := f.Close()
-		if err != nil {
-			log.Println(err)
-		}
-	}(f)
+// GetTemplate captures a screenshot to a file named by expanding the path template for the URL,
+// the options and the current time. The file is written according to params. It returns the name
+// of the written file, or a parsed Screenshot API error.
+func (service screenshotAPIServiceOp) GetTemplate(
+	ctx context.Context,
+	url string,
+	tmpl *PathTemplate,
+	params FileParams,
+	opts ...Option,
+) (string, error) {
+	if tmpl == nil {
+		return "", &ArgError{"template", "can not be nil"}
+	}
 
-	_, err = f.Write(resp.Body)
+	filename, err := tmpl.Expand(url, time.Now(), opts...)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	return service.GetFile(ctx, url, filename, params, opts...)
 }
 
 // GetRaw returns raw Screenshot API response as the Response struct with Body saved as a byte slice.
@@ -197,13 +316,31 @@ func (service screenshotAPIServiceOp) GetRaw(
 		return resp, err
 	}
 
-	if respErr := checkResponse(resp.Response); respErr != nil {
+	if respErr := checkResponse(resp.Response, resp.Body); respErr != nil {
 		return resp, respErr
 	}
 
 	return resp, nil
 }
 
+// decodeDataURI replaces the data URI body of the response with decoded data.
+// Other bodies, such as error messages, are kept.
+func decodeDataURI(resp *Response) error {
+	if !IsDataURI(resp.Body) {
+		return nil
+	}
+
+	uri, err := ParseDataURI(resp.Body)
+	if err != nil {
+		return fmt.Errorf("cannot decode response: %w", err)
+	}
+
+	resp.Body = uri.Data
+	resp.MediaType = uri.MediaType
+
+	return nil
+}
+
 // ArgError is the argument error.
 type ArgError struct {
 	Name    string
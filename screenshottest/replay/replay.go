@@ -0,0 +1,377 @@
+// Package replay records Screenshot API exchanges into cassette files and replays them, so tests
+// run without credits or network access.
+//
+// The Recorder is the http.RoundTripper used in screenshotapi.ClientParams.HTTPClient:
+//
+//	rec, err := replay.New("testdata/get.json", replay.Params{Mode: replay.ModeReplay})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Save()
+//
+//	client := screenshotapi.NewClient(apiKey, screenshotapi.ClientParams{HTTPClient: rec.Client()})
+//
+// Requests are matched by the method and the canonical query parameters, so the order of options
+// and the API endpoint don't matter. The API key is never written to cassettes.
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redacted replaces the API key in recorded responses.
+const redacted = "REDACTED"
+
+// ErrNotRecorded is returned in ModeReplay when the cassette has no matching interaction.
+var ErrNotRecorded = errors.New("replay: interaction is not recorded")
+
+// Mode specifies whether the Recorder makes real requests.
+type Mode int
+
+const (
+	// ModeReplay serves requests from the cassette only and fails with ErrNotRecorded on misses.
+	ModeReplay Mode = iota
+
+	// ModeRecord makes every request and overwrites the cassette with the recorded interactions.
+	ModeRecord
+
+	// ModeRecordMissing serves recorded requests from the cassette and records the rest.
+	ModeRecordMissing
+)
+
+// String returns the mode name.
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	case ModeRecordMissing:
+		return "record-missing"
+	}
+
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Params is used to create Recorder.
+type Params struct {
+	// Mode specifies whether real requests are made
+	// Default: ModeReplay
+	Mode Mode
+
+	// Transport makes real requests in ModeRecord and ModeRecordMissing
+	// If it's nil then http.DefaultTransport is used
+	Transport http.RoundTripper
+
+	// APIKeyHeader is the name of the header with the API key, see screenshotapi.ClientParams.APIKeyHeader
+	// The apiKey query parameter is always scrubbed
+	APIKeyHeader string
+}
+
+// Request is the recorded request.
+type Request struct {
+	Method string `json:"method"`
+
+	// Query is the query without the API key
+	Query url.Values `json:"query"`
+}
+
+// Response is the recorded response.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body"`
+}
+
+// Interaction is the recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the file with recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is the http.RoundTripper recording and replaying interactions. It's safe for concurrent use.
+type Recorder struct {
+	path   string
+	params Params
+
+	mu       sync.Mutex
+	cassette Cassette
+	played   map[string]int
+	modified bool
+}
+
+// New creates Recorder with the cassette file. The file must exist in ModeReplay.
+func New(path string, params Params) (*Recorder, error) {
+	r := &Recorder{
+		path:   path,
+		params: params,
+		played: map[string]int{},
+	}
+
+	if params.Mode == ModeRecord {
+		return r, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && params.Mode == ModeRecordMissing {
+			return r, nil
+		}
+		return nil, fmt.Errorf("cannot read cassette: %w", err)
+	}
+
+	if err = json.Unmarshal(raw, &r.cassette); err != nil {
+		return nil, fmt.Errorf("cannot parse cassette %s: %w", path, err)
+	}
+
+	return r, nil
+}
+
+// Client returns http.Client using the Recorder as the transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns the recorded interactions.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// RoundTrip implements http.RoundTripper. Interactions with the same key are replayed in the order
+// they were recorded, the last one is repeated when they are exhausted.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	query := scrubQuery(req.URL.Query())
+	key := matchKey(req.Method, query)
+
+	if r.params.Mode != ModeRecord {
+		if resp, ok := r.replay(req, key); ok {
+			return resp, nil
+		}
+
+		if r.params.Mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s", ErrNotRecorded, key)
+		}
+	}
+
+	return r.record(req, query)
+}
+
+// replay returns the recorded response matching the key.
+func (r *Recorder) replay(req *http.Request, key string) (*http.Response, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matches []int
+	for i, in := range r.cassette.Interactions {
+		if matchKey(in.Request.Method, in.Request.Query) == key {
+			matches = append(matches, i)
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	n := r.played[key]
+	if n >= len(matches) {
+		n = len(matches) - 1
+	}
+	r.played[key]++
+
+	return newResponse(req, r.cassette.Interactions[matches[n]].Response), true
+}
+
+// record makes the request and records the interaction.
+func (r *Recorder) record(req *http.Request, query url.Values) (*http.Response, error) {
+	transport := r.params.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+	}
+
+	r.scrubResponse(req, &recorded)
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:  Request{Method: req.Method, Query: query},
+		Response: recorded,
+	})
+	r.modified = true
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return resp, nil
+}
+
+// scrubResponse redacts the API key of the request echoed in the response.
+func (r *Recorder) scrubResponse(req *http.Request, resp *Response) {
+	var keys []string
+	if key := req.URL.Query().Get("apiKey"); key != "" {
+		keys = append(keys, key)
+	}
+	if r.params.APIKeyHeader != "" {
+		if key := req.Header.Get(r.params.APIKeyHeader); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		resp.Body = bytes.ReplaceAll(resp.Body, []byte(key), []byte(redacted))
+
+		for name, values := range resp.Header {
+			for i, v := range values {
+				resp.Header[name][i] = strings.ReplaceAll(v, key, redacted)
+			}
+		}
+	}
+}
+
+// Save writes the cassette if new interactions have been recorded.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.modified {
+		return nil
+	}
+
+	raw, err := json.MarshalIndent(&r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("cannot create cassette directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create cassette: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("cannot write cassette: %w", err)
+	}
+	if _, err = tmp.Write(append(raw, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("cannot write cassette: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("cannot write cassette: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("cannot write cassette: %w", err)
+	}
+
+	r.modified = false
+
+	return nil
+}
+
+// scrubQuery returns the query without the API key.
+func scrubQuery(query url.Values) url.Values {
+	q := url.Values{}
+	for name, values := range query {
+		if name != "apiKey" {
+			q[name] = values
+		}
+	}
+
+	return q
+}
+
+// matchKey returns the key matching requests regardless of the order of query parameters and their values.
+func matchKey(method string, query url.Values) string {
+	q := url.Values{}
+	for name, values := range query {
+		if name == "apiKey" {
+			continue
+		}
+		sorted := append([]string(nil), values...)
+		if name == "cookies" {
+			for i, v := range sorted {
+				sorted[i] = sortCookies(v)
+			}
+		}
+		sort.Strings(sorted)
+		q[name] = sorted
+	}
+
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	return method + " ?" + q.Encode()
+}
+
+// sortCookies returns the cookies value with the name=value pairs sorted, e.g. b=2;a=1 becomes a=1;b=2.
+func sortCookies(cookies string) string {
+	var pairs []string
+	for _, pair := range strings.Split(cookies, ";") {
+		if pair = strings.TrimSpace(pair); pair != "" {
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ";")
+}
+
+// newResponse creates http.Response of the recorded response.
+func newResponse(req *http.Request, recorded Response) *http.Response {
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,265 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	screenshotapi "github.com/whois-api-llc/screenshot-go"
+)
+
+const apiKey = "at_LoremIpsumDolorSitAmetConsect"
+
+// newAPI returns the Screenshot API stand-in, it echoes the query into the body and counts calls.
+func newAPI(calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(calls, 1)
+
+		if req.URL.Query().Get("url") == "redirect.com" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"code":422,"messages":"Hostname changed."}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("X-Echo", req.URL.RawQuery)
+		_, _ = w.Write([]byte("\xff\xd8\xff" + req.URL.RawQuery))
+	}))
+}
+
+// newClient returns the client using the recorder.
+func newClient(rec *Recorder, baseURL string) *screenshotapi.Client {
+	apiURL, err := url.Parse(baseURL)
+	if err != nil {
+		panic(err)
+	}
+
+	return screenshotapi.NewClient(apiKey, screenshotapi.ClientParams{
+		HTTPClient:           rec.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+	})
+}
+
+// TestRecordReplay tests recording the cassette and replaying it without the server.
+func TestRecordReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+
+	var calls int32
+	server := newAPI(&calls)
+
+	rec, err := New(path, Params{Mode: ModeRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newClient(rec, server.URL)
+
+	recorded, err := client.GetRaw(ctx, "example.com",
+		screenshotapi.OptionWidth(800), screenshotapi.OptionHeight(600), screenshotapi.OptionType("jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = client.GetRaw(ctx, "redirect.com"); !errors.Is(err, screenshotapi.ErrHostnameChanged) {
+		t.Fatalf("GetRaw() error = %v, want %v", err, screenshotapi.ErrHostnameChanged)
+	}
+
+	if err = rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.Close()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte(apiKey)) {
+		t.Error("the API key is recorded")
+	}
+
+	rec, err = New(path, Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the endpoint and the order of options don't matter
+	client = newClient(rec, "https://website-screenshot.whoisxmlapi.com/api/v1")
+
+	replayed, err := client.GetRaw(ctx, "example.com",
+		screenshotapi.OptionType("jpg"), screenshotapi.OptionHeight(600), screenshotapi.OptionWidth(800))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(replayed.Body, bytes.ReplaceAll(recorded.Body, []byte(apiKey), []byte(redacted))) {
+		t.Errorf("body = %q, want %q", replayed.Body, recorded.Body)
+	}
+	if replayed.Header.Get("Content-Type") != "image/jpeg" {
+		t.Errorf("Content-Type = %q, want image/jpeg", replayed.Header.Get("Content-Type"))
+	}
+	if bytes.Contains([]byte(replayed.Header.Get("X-Echo")), []byte(apiKey)) {
+		t.Error("the API key is recorded in headers")
+	}
+
+	if _, err = client.GetRaw(ctx, "redirect.com"); !errors.Is(err, screenshotapi.ErrHostnameChanged) {
+		t.Errorf("GetRaw() error = %v, want %v", err, screenshotapi.ErrHostnameChanged)
+	}
+
+	_, err = client.GetRaw(ctx, "example.com", screenshotapi.OptionWidth(1024))
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("GetRaw() error = %v, want %v", err, ErrNotRecorded)
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("server calls = %d, want 2", got)
+	}
+}
+
+// TestRecordMissing tests recording only the requests missing in the cassette.
+func TestRecordMissing(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	var calls int32
+	server := newAPI(&calls)
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		rec, err := New(path, Params{Mode: ModeRecordMissing})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client := newClient(rec, server.URL)
+
+		for _, target := range []string{"example.com", "example.org", "example.com"} {
+			if _, err = client.GetRaw(ctx, target); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err = rec.Save(); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := len(rec.Interactions()); got != 2 {
+			t.Errorf("run %d: interactions = %d, want 2", i, got)
+		}
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("server calls = %d, want 2", got)
+	}
+}
+
+// TestReplayCookies tests replaying the request with several cookies.
+func TestReplayCookies(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	var calls int32
+	server := newAPI(&calls)
+	defer server.Close()
+
+	cookies := screenshotapi.Cookies{"session": "abc", "lang": "en", "theme": "dark"}
+
+	rec, err := New(path, Params{Mode: ModeRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newClient(rec, server.URL).GetRaw(ctx, "example.com", screenshotapi.OptionCookies(cookies)); err != nil {
+		t.Fatal(err)
+	}
+	if err = rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err = New(path, Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newClient(rec, server.URL)
+
+	for _, raw := range []string{"theme=dark;lang=en;session=abc", "lang=en;session=abc;theme=dark"} {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"?url=example.com&cookies="+url.QueryEscape(raw), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := rec.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("RoundTrip() with cookies %s error = %v", raw, err)
+		}
+		resp.Body.Close()
+	}
+
+	for i := 0; i < 10; i++ {
+		if _, err = client.GetRaw(ctx, "example.com", screenshotapi.OptionCookies(cookies)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("server calls = %d, want 1", got)
+	}
+}
+
+// TestMatchKey tests matching requests.
+func TestMatchKey(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b url.Values
+		want bool
+	}{
+		{
+			name: "order",
+			a:    url.Values{"url": {"example.com"}, "width": {"800"}, "cookies": {"a=1", "b=2"}},
+			b:    url.Values{"cookies": {"b=2", "a=1"}, "width": {"800"}, "url": {"example.com"}},
+			want: true,
+		},
+		{
+			name: "cookie order",
+			a:    url.Values{"url": {"example.com"}, "cookies": {"session=abc;lang=en"}},
+			b:    url.Values{"url": {"example.com"}, "cookies": {"lang=en;session=abc"}},
+			want: true,
+		},
+		{
+			name: "different cookies",
+			a:    url.Values{"url": {"example.com"}, "cookies": {"session=abc;lang=en"}},
+			b:    url.Values{"url": {"example.com"}, "cookies": {"session=abc;lang=de"}},
+		},
+		{
+			name: "api key",
+			a:    url.Values{"url": {"example.com"}, "apiKey": {apiKey}},
+			b:    url.Values{"url": {"example.com"}},
+			want: true,
+		},
+		{
+			name: "different values",
+			a:    url.Values{"url": {"example.com"}, "width": {"800"}},
+			b:    url.Values{"url": {"example.com"}, "width": {"1024"}},
+		},
+		{
+			name: "missing option",
+			a:    url.Values{"url": {"example.com"}, "fullPage": {"true"}},
+			b:    url.Values{"url": {"example.com"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchKey(http.MethodGet, tt.a) == matchKey(http.MethodGet, tt.b); got != tt.want {
+				t.Errorf("matchKey() match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,465 @@
+// Package screenshottest provides the fake Screenshot API server for integration tests.
+//
+// The server validates requests like the real endpoint and returns generated images of the requested
+// type and size, so services can be tested without credits or network access:
+//
+//	server := screenshottest.NewServer()
+//	defer server.Close()
+//
+//	client := screenshotapi.NewClient(screenshottest.APIKey, screenshotapi.ClientParams{
+//		ScreenshotAPIBaseURL: server.BaseURL(),
+//	})
+//
+// Failures are injected with Server.Fail, and the spent credits are counted by Server.Credits.
+package screenshottest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	screenshotapi "github.com/whois-api-llc/screenshot-go"
+)
+
+// APIKey is the API key accepted by the server unless it's changed with Server.SetAPIKey.
+const APIKey = "at_ScreenshotTestApiKey00000000"
+
+// APIKeyHeader is the header the server accepts the API key in, in addition to the apiKey query parameter.
+const APIKeyHeader = "X-Authentication-Token"
+
+// defaultJPEGQuality is the JPEG quality if it's not set in the request.
+const defaultJPEGQuality = 85
+
+// Failure is the failure injected with Server.Fail.
+type Failure int
+
+const (
+	// FailRateLimited responds with 429 Too Many Requests and Retry-After: 0.
+	FailRateLimited Failure = iota + 1
+
+	// FailServerError responds with 500 Internal Server Error.
+	FailServerError
+
+	// FailServiceUnavailable responds with 503 Service Unavailable.
+	FailServiceUnavailable
+
+	// FailPageTimeout responds with 504 Gateway Timeout, as if the target page is not loaded in time.
+	FailPageTimeout
+
+	// FailHostnameChanged responds with 422 Unprocessable Entity, as if the target hostname is changed
+	// due to redirects.
+	FailHostnameChanged
+
+	// FailTruncated responds with the image cut in half.
+	FailTruncated
+)
+
+// String returns the failure name.
+func (f Failure) String() string {
+	switch f {
+	case FailRateLimited:
+		return "rate limited"
+	case FailServerError:
+		return "server error"
+	case FailServiceUnavailable:
+		return "service unavailable"
+	case FailPageTimeout:
+		return "page timeout"
+	case FailHostnameChanged:
+		return "hostname changed"
+	case FailTruncated:
+		return "truncated"
+	}
+
+	return fmt.Sprintf("Failure(%d)", int(f))
+}
+
+// errorMessage is the Screenshot API error in XML format.
+type errorMessage struct {
+	XMLName xml.Name `xml:"ErrorMessage"`
+	screenshotapi.ErrorMessage
+}
+
+// Server is the fake Screenshot API server. It's safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	apiKey    string
+	latency   time.Duration
+	balance   int
+	limited   bool
+	credits   int
+	failures  []Failure
+	redirects map[string]bool
+	requests  []url.Values
+}
+
+// NewServer starts the fake Screenshot API server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		apiKey:    APIKey,
+		redirects: map[string]bool{},
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// BaseURL returns the URL to use as screenshotapi.ClientParams.ScreenshotAPIBaseURL.
+func (s *Server) BaseURL() *url.URL {
+	u, err := url.Parse(s.URL + "/api/v1")
+	if err != nil {
+		panic(err)
+	}
+
+	return u
+}
+
+// SetAPIKey sets the API key accepted by the server.
+func (s *Server) SetAPIKey(apiKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKey = apiKey
+}
+
+// SetLatency sets the time the server takes to respond.
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = latency
+}
+
+// SetBalance limits the credits balance. Once it's spent, requests fail with 403 Forbidden.
+// The balance is unlimited by default.
+func (s *Server) SetBalance(credits int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.balance = credits
+	s.limited = true
+}
+
+// Credits returns the number of credits spent. Every successful capture costs one credit.
+func (s *Server) Credits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.credits
+}
+
+// Fail queues failures for the next requests, one failure per request.
+func (s *Server) Fail(failures ...Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, failures...)
+}
+
+// Redirect marks the host as redirecting to another one. Requests for it with failOnHostnameChange
+// fail with 422 Unprocessable Entity.
+func (s *Server) Redirect(host string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.redirects[strings.ToLower(host)] = true
+}
+
+// Requests returns the query parameters of the received requests without the API key.
+func (s *Server) Requests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]url.Values(nil), s.requests...)
+}
+
+// serveHTTP handles the Screenshot API request.
+func (s *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	apiKey := query.Get("apiKey")
+	if apiKey == "" {
+		apiKey = req.Header.Get(APIKeyHeader)
+	}
+
+	recorded := url.Values{}
+	for name, values := range query {
+		if name != "apiKey" {
+			recorded[name] = values
+		}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, recorded)
+	latency := s.latency
+	validKey := apiKey == s.apiKey
+	s.mu.Unlock()
+
+	errorsFormat := strings.ToUpper(query.Get("errorsOutputFormat"))
+
+	if !sleep(req.Context(), latency) {
+		return
+	}
+
+	if req.Method != http.MethodGet {
+		writeError(w, errorsFormat, http.StatusMethodNotAllowed, "Method not allowed.")
+		return
+	}
+
+	if !validKey {
+		writeError(w, errorsFormat, http.StatusUnauthorized, "Access restricted. Check the API key.")
+		return
+	}
+
+	if failure, ok := s.nextFailure(); ok {
+		if s.fail(w, query, errorsFormat, failure) {
+			return
+		}
+	}
+
+	target := query.Get("url")
+	if target == "" {
+		writeError(w, errorsFormat, http.StatusBadRequest, `Parameter "url" is required.`)
+		return
+	}
+
+	// the API checks the range of every parameter and the thumbnail width only, as ParseCaptureOptions does,
+	// other rules of ValidateOptions are client-side
+	opts, err := screenshotapi.ParseCaptureOptions(query)
+	if err != nil {
+		writeError(w, errorsFormat, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if opts.FailOnHostnameChange && s.redirected(target) {
+		writeError(w, errorsFormat, http.StatusUnprocessableEntity, "Hostname changed.")
+		return
+	}
+
+	if !s.charge() {
+		writeError(w, errorsFormat, http.StatusForbidden, "Access restricted. Check credits balance or enter the correct API key.")
+		return
+	}
+
+	body, contentType := render(target, opts)
+
+	writeImage(w, opts, body, contentType)
+}
+
+// nextFailure pops the next injected failure.
+func (s *Server) nextFailure() (Failure, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.failures) == 0 {
+		return 0, false
+	}
+
+	failure := s.failures[0]
+	s.failures = s.failures[1:]
+
+	return failure, true
+}
+
+// fail writes the failure response. It returns false if the failure is applied to the successful response.
+func (s *Server) fail(w http.ResponseWriter, query url.Values, errorsFormat string, failure Failure) bool {
+	switch failure {
+	case FailRateLimited:
+		w.Header().Set("Retry-After", "0")
+		writeError(w, errorsFormat, http.StatusTooManyRequests, "Too many requests.")
+	case FailServerError:
+		writeError(w, errorsFormat, http.StatusInternalServerError, "Internal server error.")
+	case FailServiceUnavailable:
+		writeError(w, errorsFormat, http.StatusServiceUnavailable, "Service unavailable.")
+	case FailPageTimeout:
+		writeError(w, errorsFormat, http.StatusGatewayTimeout, "Target page timeout.")
+	case FailHostnameChanged:
+		writeError(w, errorsFormat, http.StatusUnprocessableEntity, "Hostname changed.")
+	case FailTruncated:
+		opts, err := screenshotapi.ParseCaptureOptions(query)
+		if err != nil {
+			return false
+		}
+
+		body, contentType := render(query.Get("url"), opts)
+		writeImage(w, opts, body[:len(body)/2], contentType)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// redirected reports whether the target host is marked with Redirect.
+func (s *Server) redirected(target string) bool {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.redirects[strings.ToLower(u.Hostname())]
+}
+
+// charge spends one credit. It returns false if the balance is spent.
+func (s *Server) charge() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.limited && s.credits >= s.balance {
+		return false
+	}
+
+	s.credits++
+
+	return true
+}
+
+// sleep waits for the latency. It returns false if the request is canceled.
+func sleep(ctx context.Context, latency time.Duration) bool {
+	if latency <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// writeError writes the error message in JSON or XML format.
+func writeError(w http.ResponseWriter, format string, code int, message string) {
+	msg := errorMessage{ErrorMessage: screenshotapi.ErrorMessage{Code: code, Message: message}}
+
+	var body []byte
+
+	if format == "XML" {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		raw, _ := xml.Marshal(&msg)
+		body = append([]byte(xml.Header), raw...)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		body, _ = json.Marshal(&msg.ErrorMessage)
+	}
+
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
+
+// writeImage writes the image as is or as the data URI in base64 mode.
+func writeImage(w http.ResponseWriter, opts *screenshotapi.CaptureOptions, body []byte, contentType string) {
+	if strings.ToLower(opts.ImageOutputFormat) == "base64" {
+		body = []byte("data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(body))
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+	_, _ = w.Write(body)
+}
+
+// render generates the screenshot of the requested type and size. The image is filled with the color
+// derived from the target URL, so different targets produce different images. Thumbnails keep
+// the aspect ratio of the screenshot.
+func render(target string, opts *screenshotapi.CaptureOptions) ([]byte, string) {
+	width, height := opts.Width, opts.Height
+	if width == 0 {
+		width = screenshotapi.DefaultWidth
+	}
+	if height == 0 {
+		height = screenshotapi.DefaultHeight
+	}
+
+	if opts.ThumbWidth != 0 {
+		height = height * opts.ThumbWidth / width
+		width = opts.ThumbWidth
+	}
+
+	if strings.ToLower(opts.Type) == "pdf" {
+		return renderPDF(target, width, height), "application/pdf"
+	}
+
+	sum := sha256.Sum256([]byte(target))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill := color.RGBA{R: sum[0], G: sum[1], B: sum[2], A: 0xFF}
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = fill.R, fill.G, fill.B, fill.A
+	}
+
+	var b bytes.Buffer
+
+	if strings.ToLower(opts.Type) == "png" {
+		if err := png.Encode(&b, img); err != nil {
+			panic(err)
+		}
+		return b.Bytes(), "image/png"
+	}
+
+	quality := opts.Quality
+	if quality == 0 {
+		quality = defaultJPEGQuality
+	}
+
+	if err := jpeg.Encode(&b, img, &jpeg.Options{Quality: quality}); err != nil {
+		panic(err)
+	}
+
+	return b.Bytes(), "image/jpeg"
+}
+
+// renderPDF generates the single-page PDF document with the page of the size in pixels.
+func renderPDF(target string, width, height int) []byte {
+	var b bytes.Buffer
+
+	offsets := make([]int, 0, 3)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] >>", width, height),
+	}
+
+	b.WriteString("%PDF-1.4\n")
+	fmt.Fprintf(&b, "%% %s\n", url.QueryEscape(target))
+
+	for i, obj := range objects {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return b.Bytes()
+}
@@ -0,0 +1,311 @@
+package screenshottest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	screenshotapi "github.com/whois-api-llc/screenshot-go"
+)
+
+// newClient returns the client of the server.
+func newClient(server *Server, params screenshotapi.ClientParams) *screenshotapi.Client {
+	params.HTTPClient = server.Client()
+	params.ScreenshotAPIBaseURL = server.BaseURL()
+
+	return screenshotapi.NewClient(APIKey, params)
+}
+
+// TestServerCapture tests generated screenshots.
+func TestServerCapture(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := newClient(server, screenshotapi.ClientParams{ValidateBody: true})
+
+	tests := []struct {
+		name       string
+		opts       []screenshotapi.Option
+		wantFormat screenshotapi.ImageFormat
+		wantType   string
+		wantWidth  int
+		wantHeight int
+		wantPages  int
+	}{
+		{
+			name:       "default",
+			wantFormat: screenshotapi.FormatJPEG,
+			wantType:   "image/jpeg",
+			wantWidth:  screenshotapi.DefaultWidth,
+			wantHeight: screenshotapi.DefaultHeight,
+		},
+		{
+			name:       "png",
+			opts:       []screenshotapi.Option{screenshotapi.OptionType("png"), screenshotapi.OptionWidth(1280), screenshotapi.OptionHeight(720)},
+			wantFormat: screenshotapi.FormatPNG,
+			wantType:   "image/png",
+			wantWidth:  1280,
+			wantHeight: 720,
+		},
+		{
+			name:       "thumbnail",
+			opts:       []screenshotapi.Option{screenshotapi.OptionWidth(1000), screenshotapi.OptionHeight(500), screenshotapi.OptionThumbWidth(200)},
+			wantFormat: screenshotapi.FormatJPEG,
+			wantType:   "image/jpeg",
+			wantWidth:  200,
+			wantHeight: 100,
+		},
+		{
+			name:       "base64",
+			opts:       []screenshotapi.Option{screenshotapi.OptionImageOutputFormat("base64"), screenshotapi.OptionType("png"), screenshotapi.OptionDecodeDataURI(true)},
+			wantFormat: screenshotapi.FormatPNG,
+			wantType:   "image/png",
+			wantWidth:  screenshotapi.DefaultWidth,
+			wantHeight: screenshotapi.DefaultHeight,
+		},
+		{
+			name:       "pdf",
+			opts:       []screenshotapi.Option{screenshotapi.OptionType("pdf")},
+			wantFormat: screenshotapi.FormatPDF,
+			wantType:   "application/pdf",
+			wantPages:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := client.Capture(context.Background(), "whoisxmlapi.com", tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Format != tt.wantFormat || result.ContentType != tt.wantType {
+				t.Errorf("format = %s, %s, want %s, %s", result.Format, result.ContentType, tt.wantFormat, tt.wantType)
+			}
+			if result.Width != tt.wantWidth || result.Height != tt.wantHeight {
+				t.Errorf("size = %dx%d, want %dx%d", result.Width, result.Height, tt.wantWidth, tt.wantHeight)
+			}
+			if result.Pages != tt.wantPages {
+				t.Errorf("pages = %d, want %d", result.Pages, tt.wantPages)
+			}
+		})
+	}
+
+	if got := server.Credits(); got != len(tests) {
+		t.Errorf("Credits() = %d, want %d", got, len(tests))
+	}
+	if got := len(server.Requests()); got != len(tests) {
+		t.Errorf("Requests() = %d, want %d", got, len(tests))
+	}
+}
+
+// TestServerErrors tests error responses and injected failures.
+func TestServerErrors(t *testing.T) {
+	ctx := context.Background()
+
+	server := NewServer()
+	defer server.Close()
+
+	server.Redirect("old.example.com")
+
+	client := newClient(server, screenshotapi.ClientParams{ValidateBody: true})
+
+	tests := []struct {
+		name    string
+		fail    []Failure
+		target  string
+		opts    []screenshotapi.Option
+		wantErr error
+	}{
+		{
+			name:    "invalid options",
+			target:  "whoisxmlapi.com",
+			opts:    []screenshotapi.Option{func(v url.Values) error { v.Set("width", "50"); return nil }},
+			wantErr: screenshotapi.ErrBadRequest,
+		},
+		{
+			name:    "xml errors",
+			target:  "whoisxmlapi.com",
+			opts:    []screenshotapi.Option{screenshotapi.OptionErrorsOutputFormat("XML")},
+			fail:    []Failure{FailServerError},
+			wantErr: screenshotapi.ErrServerError,
+		},
+		{
+			name:    "redirect",
+			target:  "https://OLD.example.com/path",
+			opts:    []screenshotapi.Option{screenshotapi.OptionFailOnHostnameChange(true)},
+			wantErr: screenshotapi.ErrHostnameChanged,
+		},
+		{
+			name:    "hostname changed",
+			target:  "whoisxmlapi.com",
+			fail:    []Failure{FailHostnameChanged},
+			wantErr: screenshotapi.ErrHostnameChanged,
+		},
+		{
+			name:    "rate limited",
+			target:  "whoisxmlapi.com",
+			fail:    []Failure{FailRateLimited},
+			wantErr: screenshotapi.ErrRateLimited,
+		},
+		{
+			name:    "page timeout",
+			target:  "whoisxmlapi.com",
+			fail:    []Failure{FailPageTimeout},
+			wantErr: screenshotapi.ErrPageTimeout,
+		},
+		{
+			name:    "truncated",
+			target:  "whoisxmlapi.com",
+			fail:    []Failure{FailTruncated},
+			wantErr: screenshotapi.ErrInvalidBody,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.Fail(tt.fail...)
+
+			_, err := client.GetRaw(ctx, tt.target, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetRaw() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var msg *screenshotapi.ErrorMessage
+			if tt.wantErr != screenshotapi.ErrInvalidBody && (!errors.As(err, &msg) || msg.Message == "") {
+				t.Errorf("GetRaw() error = %v, want the API error message", err)
+			}
+		})
+	}
+
+	if got := server.Credits(); got != 0 {
+		t.Errorf("Credits() = %d, want 0", got)
+	}
+}
+
+// TestServerOptions tests that the server checks the options like the API does: the range of every
+// parameter and the thumbnail width, but not the client-side rules of ValidateOptions.
+func TestServerOptions(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{
+			name:  "valid",
+			query: "width=1024&height=768",
+			want:  http.StatusOK,
+		},
+		{
+			name:  "out of range",
+			query: "width=50",
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "not a number",
+			query: "quality=high",
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "thumbnail wider than screenshot",
+			query: "width=500&thumbWidth=600",
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "thumbnail wider than default width",
+			query: "thumbWidth=1000",
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "quality with png",
+			query: "type=png&quality=50",
+			want:  http.StatusOK,
+		},
+		{
+			name:  "landscape without mobile",
+			query: "landscape=true",
+			want:  http.StatusOK,
+		},
+		{
+			name:  "scrollPosition without scroll",
+			query: "scrollPosition=bottom",
+			want:  http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := server.Client().Get(server.BaseURL().String() + "?apiKey=" + APIKey + "&url=whoisxmlapi.com&" + tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+// TestServerRetry tests that the client recovers from injected failures.
+func TestServerRetry(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.Fail(FailRateLimited, FailServiceUnavailable)
+
+	policy := screenshotapi.DefaultRetryPolicy()
+	policy.BaseBackoff = time.Millisecond
+	policy.MaxBackoff = time.Millisecond
+
+	client := newClient(server, screenshotapi.ClientParams{RetryPolicy: policy})
+
+	if _, err := client.GetRaw(context.Background(), "whoisxmlapi.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(server.Requests()); got != 3 {
+		t.Errorf("Requests() = %d, want 3", got)
+	}
+}
+
+// TestServerAccess tests the API key, the balance and the latency.
+func TestServerAccess(t *testing.T) {
+	ctx := context.Background()
+
+	server := NewServer()
+	defer server.Close()
+
+	client := newClient(server, screenshotapi.ClientParams{})
+
+	server.SetAPIKey("at_other")
+	if _, err := client.GetRaw(ctx, "whoisxmlapi.com"); !errors.Is(err, screenshotapi.ErrInvalidAPIKey) {
+		t.Errorf("GetRaw() error = %v, want %v", err, screenshotapi.ErrInvalidAPIKey)
+	}
+
+	server.SetAPIKey(APIKey)
+	server.SetBalance(1)
+
+	if _, err := client.GetRaw(ctx, "whoisxmlapi.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetRaw(ctx, "whoisxmlapi.com"); !errors.Is(err, screenshotapi.ErrInsufficientCredits) {
+		t.Errorf("GetRaw() error = %v, want %v", err, screenshotapi.ErrInsufficientCredits)
+	}
+
+	server.SetLatency(time.Second)
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.GetRaw(ctx, "whoisxmlapi.com"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetRaw() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
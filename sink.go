@@ -0,0 +1,270 @@
+package screenshotapi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SinkMetadata describes the stored object.
+type SinkMetadata struct {
+	// ContentType is the MIME type of the object, e.g. image/png
+	ContentType string
+
+	// Fields are arbitrary key-value pairs stored with the object where the sink supports it,
+	// e.g. as S3 user metadata
+	Fields map[string]string
+}
+
+// Sink stores captured screenshots. Keys are slash-separated relative paths, e.g. example.com/home.png.
+// Implementations must be safe for concurrent use.
+type Sink interface {
+	// Put stores the object read from r under the key. Use bytes.NewReader to store a byte slice.
+	Put(ctx context.Context, key string, r io.Reader, meta SinkMetadata) error
+}
+
+// ErrInvalidKey is returned by sinks for empty, absolute or parent-relative keys.
+var ErrInvalidKey = errors.New("invalid sink key")
+
+// cleanKey validates the key and returns its clean form.
+func cleanKey(key string) (string, error) {
+	key = strings.ReplaceAll(key, "\\", "/")
+
+	if key == "" || strings.HasPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+
+	key = path.Clean(key)
+	if key == "." {
+		return "", ErrInvalidKey
+	}
+
+	return key, nil
+}
+
+// FileSink stores objects as files under the directory. Metadata is not stored.
+type FileSink struct {
+	dir    string
+	params FileParams
+}
+
+// NewFileSink creates FileSink writing files under dir according to params.
+// Parent directories of keys are always created.
+func NewFileSink(dir string, params FileParams) *FileSink {
+	params.CreateDirs = true
+
+	return &FileSink{dir: dir, params: params}
+}
+
+// Put implements Sink.
+func (s *FileSink) Put(ctx context.Context, key string, r io.Reader, _ SinkMetadata) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err = WriteFile(filepath.Join(s.dir, filepath.FromSlash(key)), r, s.params)
+
+	return err
+}
+
+// MemoryObject is the object stored in MemorySink.
+type MemoryObject struct {
+	Data     []byte
+	Metadata SinkMetadata
+}
+
+// MemorySink stores objects in memory. It's useful for tests and post-processing.
+type MemorySink struct {
+	mu      sync.RWMutex
+	objects map[string]MemoryObject
+}
+
+// NewMemorySink creates empty MemorySink.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{objects: map[string]MemoryObject{}}
+}
+
+// Put implements Sink.
+func (s *MemorySink) Put(ctx context.Context, key string, r io.Reader, meta SinkMetadata) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.objects[key] = MemoryObject{Data: data, Metadata: meta}
+
+	return nil
+}
+
+// Get returns the object stored under the key.
+func (s *MemorySink) Get(key string) (MemoryObject, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	obj, ok := s.objects[key]
+
+	return obj, ok
+}
+
+// Keys returns the sorted keys of the stored objects.
+func (s *MemorySink) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// ZipSink stores objects as entries of the zip archive. Metadata is not stored.
+// Close must be called to write the archive directory.
+type ZipSink struct {
+	mu sync.Mutex
+	w  *zip.Writer
+}
+
+// NewZipSink creates ZipSink writing the archive to w.
+func NewZipSink(w io.Writer) *ZipSink {
+	return &ZipSink{w: zip.NewWriter(w)}
+}
+
+// Put implements Sink.
+func (s *ZipSink) Put(ctx context.Context, key string, r io.Reader, _ SinkMetadata) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// screenshots are already compressed
+	w, err := s.w.CreateHeader(&zip.FileHeader{
+		Name:     key,
+		Method:   zip.Store,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, r)
+
+	return err
+}
+
+// Close finishes the archive. It doesn't close the underlying writer.
+func (s *ZipSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Close()
+}
+
+// TarSink stores objects as entries of the tar archive. Metadata is stored as PAX records
+// with the SCREENSHOT. prefix. Close must be called to write the archive trailer.
+type TarSink struct {
+	mu sync.Mutex
+	w  *tar.Writer
+}
+
+// NewTarSink creates TarSink writing the archive to w.
+func NewTarSink(w io.Writer) *TarSink {
+	return &TarSink{w: tar.NewWriter(w)}
+}
+
+// Put implements Sink.
+func (s *TarSink) Put(ctx context.Context, key string, r io.Reader, meta SinkMetadata) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	// the entry size must be known before the data is written
+	var b bytes.Buffer
+	if _, err = io.Copy(&b, r); err != nil {
+		return err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     key,
+		Size:     int64(b.Len()),
+		Mode:     int64(DefaultFilePerm),
+		ModTime:  time.Now(),
+		Format:   tar.FormatPAX,
+	}
+
+	if meta.ContentType != "" || len(meta.Fields) != 0 {
+		hdr.PAXRecords = map[string]string{}
+		if meta.ContentType != "" {
+			hdr.PAXRecords["SCREENSHOT.content-type"] = meta.ContentType
+		}
+		for k, v := range meta.Fields {
+			hdr.PAXRecords["SCREENSHOT."+k] = v
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = s.w.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err = s.w.Write(b.Bytes())
+
+	return err
+}
+
+// Close finishes the archive. It doesn't close the underlying writer.
+func (s *TarSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Close()
+}
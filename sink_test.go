@@ -0,0 +1,203 @@
+package screenshotapi
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCleanKey tests the cleanKey function.
+func TestCleanKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "home.png", want: "home.png"},
+		{key: "example.com/./home.png", want: "example.com/home.png"},
+		{key: "example.com\\home.png", want: "example.com/home.png"},
+		{key: "", wantErr: true},
+		{key: ".", wantErr: true},
+		{key: "/etc/passwd", wantErr: true},
+		{key: "a/../../home.png", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := cleanKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cleanKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("cleanKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSinks tests storing objects in the local sinks.
+func TestSinks(t *testing.T) {
+	ctx := context.Background()
+
+	meta := SinkMetadata{ContentType: "image/png", Fields: map[string]string{"url": "example.com"}}
+
+	t.Run("file", func(t *testing.T) {
+		dir := t.TempDir()
+		sink := NewFileSink(dir, FileParams{})
+
+		if err := sink.Put(ctx, "example.com/home.png", strings.NewReader("data"), meta); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := os.ReadFile(filepath.Join(dir, "example.com", "home.png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "data" {
+			t.Errorf("file = %q, want %q", got, "data")
+		}
+
+		if err = sink.Put(ctx, "../home.png", strings.NewReader("data"), meta); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put() error = %v, want %v", err, ErrInvalidKey)
+		}
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		sink := NewMemorySink()
+
+		if err := sink.Put(ctx, "b.png", strings.NewReader("b"), meta); err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Put(ctx, "a.png", strings.NewReader("a"), SinkMetadata{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := strings.Join(sink.Keys(), ","); got != "a.png,b.png" {
+			t.Errorf("Keys() = %s, want a.png,b.png", got)
+		}
+
+		obj, ok := sink.Get("b.png")
+		if !ok || string(obj.Data) != "b" || obj.Metadata.ContentType != "image/png" {
+			t.Errorf("Get() = %+v, %v", obj, ok)
+		}
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		var b bytes.Buffer
+		sink := NewZipSink(&b)
+
+		if err := sink.Put(ctx, "example.com/home.png", strings.NewReader("data"), meta); err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.File) != 1 || r.File[0].Name != "example.com/home.png" {
+			t.Fatalf("archive files = %v", r.File)
+		}
+
+		f, err := r.File[0].Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		if got, _ := io.ReadAll(f); string(got) != "data" {
+			t.Errorf("entry = %q, want %q", got, "data")
+		}
+	})
+
+	t.Run("tar", func(t *testing.T) {
+		var b bytes.Buffer
+		sink := NewTarSink(&b)
+
+		if err := sink.Put(ctx, "example.com/home.png", strings.NewReader("data"), meta); err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		r := tar.NewReader(&b)
+
+		hdr, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name != "example.com/home.png" || hdr.PAXRecords["SCREENSHOT.content-type"] != "image/png" ||
+			hdr.PAXRecords["SCREENSHOT.url"] != "example.com" {
+			t.Errorf("header = %+v", hdr)
+		}
+
+		if got, _ := io.ReadAll(r); string(got) != "data" {
+			t.Errorf("entry = %q, want %q", got, "data")
+		}
+	})
+}
+
+// TestScreenshotAPICaptureTo tests the CaptureTo function.
+func TestScreenshotAPICaptureTo(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("url") == "nocredits.com" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"code":403,"messages":"Access restricted. Check credits balance."}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(pngImage(10, 10))
+	}))
+	defer server.Close()
+
+	apiURL, _ := url.Parse(server.URL)
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+	})
+
+	sink := NewMemorySink()
+
+	res, err := api.CaptureTo(ctx, "example.com", sink, "example.com.png", OptionType("png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj, ok := sink.Get("example.com.png")
+	if !ok {
+		t.Fatal("the screenshot is not stored")
+	}
+	if !bytes.Equal(obj.Data, pngImage(10, 10)) {
+		t.Error("stored data mismatch")
+	}
+	if obj.Metadata.ContentType != "image/png" || obj.Metadata.Fields["sha256"] != res.SHA256 {
+		t.Errorf("metadata = %+v", obj.Metadata)
+	}
+
+	if _, err = api.CaptureTo(ctx, "nocredits.com", sink, "nocredits.png"); !errors.Is(err, ErrInsufficientCredits) {
+		t.Errorf("CaptureTo() error = %v, want %v", err, ErrInsufficientCredits)
+	}
+	if _, ok = sink.Get("nocredits.png"); ok {
+		t.Error("the failed screenshot is stored")
+	}
+
+	if _, err = api.CaptureTo(ctx, "example.com", sink, ""); !errors.As(err, new(*ArgError)) {
+		t.Errorf("CaptureTo() error = %v, want *ArgError", err)
+	}
+}
@@ -0,0 +1,164 @@
+package screenshotapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+const (
+	// sniffLen is the number of leading bytes inspected to detect an error payload.
+	sniffLen = 512
+
+	// maxErrorBodySize is the maximum size of an error payload read into memory.
+	maxErrorBodySize = 1 << 20
+)
+
+// ResponseMeta describes the streamed Screenshot API response.
+type ResponseMeta struct {
+	// StatusCode is the HTTP status code
+	StatusCode int
+
+	// Header is the HTTP response header
+	Header http.Header
+
+	// ContentType is the value of the Content-Type header
+	ContentType string
+
+	// ContentLength is the body size in bytes, or -1 if unknown
+	ContentLength int64
+}
+
+// newResponseMeta creates ResponseMeta from the http.Response.
+func newResponseMeta(resp *http.Response) *ResponseMeta {
+	return &ResponseMeta{
+		StatusCode:    resp.StatusCode,
+		Header:        resp.Header,
+		ContentType:   resp.Header.Get("Content-Type"),
+		ContentLength: resp.ContentLength,
+	}
+}
+
+// streamBody is the response body which releases the in-flight slot when closed.
+type streamBody struct {
+	io.Reader
+
+	body    io.Closer
+	release func()
+	once    sync.Once
+}
+
+// Close closes the response body.
+func (s *streamBody) Close() error {
+	s.once.Do(s.release)
+	return s.body.Close()
+}
+
+// GetReader returns the screenshot as a stream which must be closed by the caller,
+// or a parsed Screenshot API error.
+func (service screenshotAPIServiceOp) GetReader(
+	ctx context.Context,
+	url string,
+	opts ...Option,
+) (io.ReadCloser, *ResponseMeta, error) {
+	req, _, err := service.captureRequest(url, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, release, err := service.client.retry(ctx, func() (*http.Response, error) {
+		return service.client.send(ctx, req)
+	}, func(resp *http.Response) {
+		// the body of the retried response is drained, so the connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodySize))
+		_ = resp.Body.Close()
+	})
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+
+	meta := newResponseMeta(resp)
+
+	body, err := checkStream(resp)
+	if err != nil {
+		_ = resp.Body.Close()
+		release()
+		return nil, meta, err
+	}
+
+	return &streamBody{Reader: body, body: resp.Body, release: release}, meta, nil
+}
+
+// GetTo captures a screenshot and streams it to w, or returns a parsed Screenshot API error.
+func (service screenshotAPIServiceOp) GetTo(
+	ctx context.Context,
+	url string,
+	w io.Writer,
+	opts ...Option,
+) (err error) {
+	if w == nil {
+		return &ArgError{"writer", "can not be nil"}
+	}
+
+	body, _, err := service.GetReader(ctx, url, opts...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if cerr := body.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("cannot close response: %w", cerr)
+		}
+	}()
+
+	if _, err = io.Copy(w, body); err != nil {
+		return fmt.Errorf("cannot read response: %w", err)
+	}
+
+	return nil
+}
+
+// checkStream inspects the leading bytes and the status code of the response and returns either
+// the reader for the whole body or the parsed Screenshot API error.
+func checkStream(resp *http.Response) (io.Reader, error) {
+	br := bufio.NewReaderSize(resp.Body, sniffLen)
+
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("cannot read response: %w", err)
+	}
+
+	var body io.Reader = br
+
+	if c := resp.StatusCode; looksLikeErrorMessage(head) || c < 200 || c > 299 {
+		raw, err := io.ReadAll(io.LimitReader(br, maxErrorBodySize))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read response: %w", err)
+		}
+
+		if respErr := checkResponse(resp, raw); respErr != nil {
+			return nil, respErr
+		}
+
+		if msg := parseErrorMessage(raw); msg != nil {
+			return nil, msg
+		}
+
+		body = io.MultiReader(bytes.NewReader(raw), br)
+	}
+
+	return body, nil
+}
+
+// looksLikeErrorMessage reports whether the leading bytes of the body look like a JSON or XML document.
+// Images, PDF documents and data URIs never start with these characters.
+func looksLikeErrorMessage(head []byte) bool {
+	head = bytes.TrimLeft(head, " \t\r\n")
+
+	return len(head) > 0 && (head[0] == '{' || head[0] == '<')
+}
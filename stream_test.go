@@ -0,0 +1,109 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+// TestScreenshotAPIGetTo tests the GetTo function.
+func TestScreenshotAPIGetTo(t *testing.T) {
+	ctx := context.Background()
+
+	const resp = `data:image/jpeg;base64,/9j/4AAQSkZJRgABAQAAAQABAAD/2wCEAAEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBA`
+
+	const respUnparsable = `<?xml version="1.0" encoding="utf-8"?><>`
+
+	const errResp = `<?xml version="1.0" encoding="utf-8"?><ErrorMessage><code>499</code><messages>Test error message.</messages></ErrorMessage>`
+
+	server := dummyServer(resp, respUnparsable, errResp)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		url     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "successful request",
+			path: pathScreenshotAPIResponseOK,
+			url:  "whoisxmlapi.com",
+			want: resp,
+		},
+		{
+			name:    "non 200 status code",
+			path:    pathScreenshotAPIResponse500,
+			url:     "whoisxmlapi.com",
+			wantErr: "API failed with status code: 500",
+		},
+		{
+			name:    "partial response 2",
+			path:    pathScreenshotAPIResponsePartial2,
+			url:     "whoisxmlapi.com",
+			wantErr: "cannot read response: unexpected EOF",
+		},
+		{
+			name:    "could not process request",
+			path:    pathScreenshotAPIResponseError,
+			url:     "whoisxmlapi.com",
+			wantErr: "API failed with status code: 499 (Test error message.)",
+		},
+		{
+			name: "unparsable response",
+			path: pathScreenshotAPIResponseUnparsable,
+			url:  "whoisxmlapi.com",
+			want: respUnparsable,
+		},
+		{
+			name:    "invalid argument",
+			path:    pathScreenshotAPIResponseOK,
+			url:     "",
+			wantErr: `invalid argument: "URL" can not be empty`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newAPI(server, tt.path)
+
+			var b bytes.Buffer
+
+			err := api.GetTo(ctx, tt.url, &b, OptionErrorsOutputFormat("XML"))
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("ScreenshotAPI.GetTo() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got := b.String(); got != tt.want {
+				t.Errorf("ScreenshotAPI.GetTo() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestScreenshotAPIGetReader tests the GetReader function.
+func TestScreenshotAPIGetReader(t *testing.T) {
+	const resp = `%PDF-1.4 lorem ipsum dolor sit amet %%EOF`
+
+	server := dummyServer(resp, "", `{"code":499,"messages":"Test error message."}`)
+	defer server.Close()
+
+	api := newAPI(server, pathScreenshotAPIResponseOK)
+
+	body, meta, err := api.GetReader(context.Background(), "whoisxmlapi.com", OptionType("pdf"))
+	if err != nil {
+		t.Fatalf("ScreenshotAPI.GetReader() error = %v", err)
+	}
+	defer body.Close()
+
+	if meta.StatusCode != 200 {
+		t.Errorf("ResponseMeta.StatusCode = %d, want 200", meta.StatusCode)
+	}
+
+	got, err := io.ReadAll(body)
+	if err != nil || string(got) != resp {
+		t.Errorf("ScreenshotAPI.GetReader() got = %s, %v, want %s", got, err, resp)
+	}
+}
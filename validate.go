@@ -0,0 +1,109 @@
+package screenshotapi
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+)
+
+var (
+	jpegEOI  = []byte{0xFF, 0xD9}
+	pngIEND  = []byte{0x00, 0x00, 0x00, 0x00, 'I', 'E', 'N', 'D', 0xAE, 0x42, 0x60, 0x82}
+	pdfEOF   = []byte("%%EOF")
+	pdfTrail = "\r\n\t \x00"
+)
+
+// ValidationError is returned when the response body doesn't match the requested image type
+// or is truncated (see ClientParams.ValidateBody). It satisfies errors.Is(err, ErrInvalidBody).
+type ValidationError struct {
+	// Expected is the requested format
+	Expected ImageFormat
+
+	// Detected is the format detected by the magic bytes of the body
+	Detected ImageFormat
+
+	// Reason describes the problem
+	Reason string
+}
+
+// Error returns error message as a string.
+func (e *ValidationError) Error() string {
+	return "invalid response body: " + e.Reason
+}
+
+// Is reports whether target is ErrInvalidBody.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidBody
+}
+
+// ValidateBody checks that data is a complete document of the expected format: the magic bytes
+// match and the JPEG EOI marker, the PNG IEND chunk or the PDF %%EOF marker is present at the end.
+func ValidateBody(expected ImageFormat, data []byte) error {
+	detected := DetectFormat(data)
+
+	if detected != expected {
+		name := string(detected)
+		if detected == FormatUnknown {
+			name = "unknown format"
+		}
+
+		return &ValidationError{
+			Expected: expected,
+			Detected: detected,
+			Reason:   "expected " + string(expected) + ", got " + name,
+		}
+	}
+
+	var complete bool
+
+	switch detected {
+	case FormatJPEG:
+		complete = bytes.HasSuffix(data, jpegEOI)
+	case FormatPNG:
+		complete = bytes.HasSuffix(data, pngIEND)
+	case FormatPDF:
+		complete = bytes.HasSuffix(bytes.TrimRight(data, pdfTrail), pdfEOF)
+	}
+
+	if !complete {
+		return &ValidationError{
+			Expected: expected,
+			Detected: detected,
+			Reason:   string(detected) + " is truncated",
+		}
+	}
+
+	return nil
+}
+
+// validateResponse validates the successful response body against the image type requested with query.
+// Error payloads are not validated, they are reported by the callers.
+func validateResponse(query url.Values, resp *Response) error {
+	if resp.Response == nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil
+	}
+
+	if parseErrorMessage(resp.Body) != nil {
+		return nil
+	}
+
+	expected := ImageFormat(strings.ToLower(query.Get("type")))
+	if expected == FormatUnknown {
+		expected = FormatJPEG
+	}
+
+	body := resp.Body
+
+	if strings.ToLower(query.Get("imageOutputFormat")) == "base64" {
+		uri, err := ParseDataURI(body)
+		if err != nil {
+			return &ValidationError{
+				Expected: expected,
+				Reason:   err.Error(),
+			}
+		}
+		body = uri.Data
+	}
+
+	return ValidateBody(expected, body)
+}
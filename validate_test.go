@@ -0,0 +1,91 @@
+package screenshotapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestValidateBody tests the ValidateBody function.
+func TestValidateBody(t *testing.T) {
+	img := pngImage(100, 100)
+
+	tests := []struct {
+		name     string
+		expected ImageFormat
+		data     []byte
+		wantErr  bool
+	}{
+		{"png", FormatPNG, img, false},
+		{"truncated png", FormatPNG, img[:len(img)-10], true},
+		{"jpg", FormatJPEG, []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0xFF, 0xD9}, false},
+		{"truncated jpg", FormatJPEG, []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00}, true},
+		{"pdf", FormatPDF, []byte("%PDF-1.4\n%%EOF\n"), false},
+		{"truncated pdf", FormatPDF, []byte("%PDF-1.4\n"), true},
+		{"mismatch", FormatJPEG, img, true},
+		{"html", FormatJPEG, []byte("<html></html>"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBody(tt.expected, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidBody) {
+				t.Errorf("ValidateBody() error = %v, want ErrInvalidBody", err)
+			}
+		})
+	}
+}
+
+// TestValidateBodyRetry tests that the truncated body is rejected and retried.
+func TestValidateBodyRetry(t *testing.T) {
+	img := pngImage(100, 100)
+
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			_, _ = w.Write(img[:len(img)/2])
+			return
+		}
+		_, _ = w.Write(img)
+	}))
+	defer server.Close()
+
+	apiURL, _ := url.Parse(server.URL)
+
+	api := NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+		ValidateBody:         true,
+	})
+
+	_, err := api.GetRaw(context.Background(), "whoisxmlapi.com", OptionType("png"))
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Detected != FormatPNG {
+		t.Errorf("ScreenshotAPI.GetRaw() error = %v, want *ValidationError", err)
+	}
+
+	api = NewClient(apiKey, ClientParams{
+		HTTPClient:           server.Client(),
+		ScreenshotAPIBaseURL: apiURL,
+		ValidateBody:         true,
+		RetryPolicy:          &RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond},
+	})
+
+	atomic.StoreInt32(&calls, 0)
+
+	if _, err = api.GetRaw(context.Background(), "whoisxmlapi.com", OptionType("png")); err != nil {
+		t.Errorf("ScreenshotAPI.GetRaw() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
@@ -0,0 +1,269 @@
+package screenshotapi
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ArgErrors is the list of argument errors reported at once.
+type ArgErrors []*ArgError
+
+// Error returns error messages separated by newlines.
+func (e ArgErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Is reports whether any error in the list matches target, so errors.Is looks into the list.
+func (e ArgErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error in the list that matches target, so every *ArgError can be extracted with errors.As.
+func (e ArgErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorOrNil returns nil for the empty list, the only error for the list of one error and the list itself otherwise.
+func (e ArgErrors) errorOrNil() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	}
+	return e
+}
+
+// optionErrors is the list of argument errors and errors returned by custom options.
+type optionErrors []error
+
+// Error returns error messages separated by newlines.
+func (e optionErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Is reports whether any error in the list matches target, so errors.Is looks into the list.
+func (e optionErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error in the list that matches target, so errors.As looks into the list.
+func (e optionErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinOptionErrors combines the argument errors with the errors returned by custom options. Errors of
+// custom options are kept unchanged, the only one is returned as is, like a single *ArgError.
+func joinOptionErrors(errs ArgErrors, custom []error) error {
+	switch {
+	case len(custom) == 0:
+		return errs.errorOrNil()
+	case len(custom) == 1 && len(errs) == 0:
+		return custom[0]
+	}
+
+	all := make(optionErrors, 0, len(errs)+len(custom))
+	for _, err := range errs {
+		all = append(all, err)
+	}
+
+	return append(all, custom...)
+}
+
+// optionRule checks the relationship between options and returns nil if it's satisfied.
+type optionRule func(v url.Values) *ArgError
+
+// thumbWidthRule checks that the thumbnail is not wider than the screenshot.
+func thumbWidthRule(v url.Values) *ArgError {
+	width, err := strconv.Atoi(v.Get("width"))
+	if err != nil {
+		width = 0
+	}
+	thumbWidth, err := strconv.Atoi(v.Get("thumbWidth"))
+	if err != nil {
+		thumbWidth = 0
+	}
+	if (width != 0 && thumbWidth > width) || (width == 0 && thumbWidth > DefaultWidth) {
+		return &ArgError{"thumbWidth", "must be between 50 and width param value"}
+	}
+	return nil
+}
+
+// optionRules are the relationships between options checked by ValidateOptions.
+var optionRules = []optionRule{
+	thumbWidthRule,
+	// thumbnails are scaled raster images, so they don't apply to pdf documents
+	func(v url.Values) *ArgError {
+		if v.Get("thumbWidth") != "" && v.Get("type") == "pdf" {
+			return &ArgError{"thumbWidth", "can not be used with pdf type"}
+		}
+		return nil
+	},
+	func(v url.Values) *ArgError {
+		if t := v.Get("type"); v.Get("quality") != "" && t != "" && t != "jpg" {
+			return &ArgError{"quality", "can be used only with jpg type"}
+		}
+		return nil
+	},
+	func(v url.Values) *ArgError {
+		if v.Get("scrollPosition") != "" && v.Get("scroll") == "" {
+			return &ArgError{"scrollPosition", "requires scroll"}
+		}
+		return nil
+	},
+	func(v url.Values) *ArgError {
+		if v.Get("landscape") != "" && v.Get("mobile") == "" {
+			return &ArgError{"landscape", "requires mobile"}
+		}
+		return nil
+	},
+	func(v url.Values) *ArgError {
+		if v.Get("touchScreen") != "" && v.Get("mobile") == "" {
+			return &ArgError{"touchScreen", "requires mobile"}
+		}
+		return nil
+	},
+}
+
+// applyOptions applies every option to v and collects all errors: argument errors and errors of custom
+// options, which are kept unchanged even if they wrap *ArgError. An option failing with an error leaves
+// v unchanged. If strict is true, options setting the same parameter to different values are reported
+// and the first value is kept, otherwise the last one wins. Parameters set by presets, options setting
+// several parameters at once such as OptionDevice, are overridden by later options without a conflict.
+func applyOptions(v url.Values, strict bool, opts ...Option) (errs ArgErrors, custom []error) {
+	// preset are the parameters last set by presets
+	preset := map[string]bool{}
+
+	for _, opt := range opts {
+		if opt == nil {
+			errs = append(errs, &ArgError{"Option", "can not be nil"})
+			continue
+		}
+
+		prev := copyValues(v)
+
+		if err := opt(v); err != nil {
+			restoreValues(v, prev)
+
+			switch err := err.(type) {
+			case *ArgError:
+				errs = append(errs, err)
+			case ArgErrors:
+				errs = append(errs, err...)
+			default:
+				custom = append(custom, err)
+			}
+			continue
+		}
+
+		if !strict {
+			continue
+		}
+
+		changed := changedParams(prev, v)
+
+		for _, name := range changed {
+			old, wasSet := prev[name]
+			if _, isSet := v[name]; wasSet && isSet && !preset[name] {
+				errs = append(errs, &ArgError{name, "is set more than once with different values"})
+				v[name] = old
+				continue
+			}
+			preset[name] = len(changed) > 1
+		}
+	}
+
+	return errs, custom
+}
+
+// copyValues returns the deep copy of v.
+func copyValues(v url.Values) url.Values {
+	c := make(url.Values, len(v))
+	for name, values := range v {
+		c[name] = append([]string(nil), values...)
+	}
+	return c
+}
+
+// restoreValues replaces the content of v with prev.
+func restoreValues(v, prev url.Values) {
+	for name := range v {
+		delete(v, name)
+	}
+	for name, values := range prev {
+		v[name] = values
+	}
+}
+
+// changedParams returns the sorted names of the parameters which are added, removed or changed in v since prev.
+func changedParams(prev, v url.Values) []string {
+	var names []string
+
+	for name, values := range v {
+		if old, ok := prev[name]; !ok || strings.Join(old, ",") != strings.Join(values, ",") {
+			names = append(names, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := v[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// ValidateOptions checks every option and the relationships between them, and reports all problems at once.
+// It returns nil, a single *ArgError or ArgErrors. Errors of custom options which aren't *ArgError are
+// kept unchanged: the only error is returned as is, otherwise all errors are returned in the list
+// which can be matched with errors.Is and errors.As. Besides the checks made for every request, it reports
+// quality without jpg type, thumbWidth with pdf type, scrollPosition without scroll, landscape or touchScreen
+// without mobile, and conflicting duplicate options. Options passed after OptionDevice override the device
+// settings without a conflict. thumbWidth isn't checked against height-limited layouts:
+// the API documents no height constraint for thumbnails.
+func ValidateOptions(opts ...Option) error {
+	v := url.Values{}
+
+	errs, custom := applyOptions(v, true, opts...)
+
+	for _, rule := range optionRules {
+		if err := rule(v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return joinOptionErrors(errs, custom)
+}
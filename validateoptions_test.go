@@ -0,0 +1,190 @@
+package screenshotapi
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// TestValidateOptions tests the ValidateOptions function.
+func TestValidateOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "valid",
+			opts: []Option{OptionType("jpg"), OptionQuality(50), OptionMobile(true), OptionLandscape(true)},
+			want: nil,
+		},
+		{
+			name: "single error",
+			opts: []Option{OptionWidth(50)},
+			want: []string{"width"},
+		},
+		{
+			name: "every option error",
+			opts: []Option{OptionWidth(50), nil, OptionQuality(10), OptionMode("turbo")},
+			want: []string{"width", "Option", "quality", "mode"},
+		},
+		{
+			name: "cross-field errors",
+			opts: []Option{
+				OptionType("png"),
+				OptionQuality(50),
+				OptionScrollPosition("bottom"),
+				OptionLandscape(true),
+				OptionTouchScreen(true),
+			},
+			want: []string{"quality", "scrollPosition", "landscape", "touchScreen"},
+		},
+		{
+			name: "thumbWidth",
+			opts: []Option{OptionType("pdf"), OptionWidth(200), OptionThumbWidth(300)},
+			want: []string{"thumbWidth", "thumbWidth"},
+		},
+		{
+			name: "device override",
+			opts: []Option{OptionDevice("iphone-14"), OptionWidth(500), OptionLandscape(true), OptionWidth(500)},
+			want: nil,
+		},
+		{
+			name: "device after option",
+			opts: []Option{OptionWidth(500), OptionDevice("iphone-14")},
+			want: []string{"width"},
+		},
+		{
+			name: "conflicting duplicates",
+			opts: []Option{OptionType("png"), OptionType("jpg"), OptionWidth(200), OptionWidth(200)},
+			want: []string{"type"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOptions(tt.opts...)
+
+			var got []string
+
+			var argErrs ArgErrors
+			var argErr *ArgError
+			switch {
+			case errors.As(err, &argErrs):
+				for _, e := range argErrs {
+					got = append(got, e.Name)
+				}
+			case errors.As(err, &argErr):
+				got = []string{argErr.Name}
+			case err != nil:
+				t.Fatalf("ValidateOptions() error = %v, want ArgErrors", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSetOptions tests that setOptions reports every invalid option but allows overriding options.
+func TestSetOptions(t *testing.T) {
+	v := map[string][]string{}
+
+	err := setOptions(v, OptionImageOutputFormat("base64"), OptionImageOutputFormat("image"))
+	if err != nil || v["imageOutputFormat"][0] != "image" {
+		t.Errorf("setOptions() error = %v, values = %v", err, v)
+	}
+
+	err = setOptions(v, OptionWidth(50), OptionHeight(50))
+
+	var argErrs ArgErrors
+	if !errors.As(err, &argErrs) || len(argErrs) != 2 {
+		t.Errorf("setOptions() error = %v, want 2 errors", err)
+	}
+}
+
+// TestCustomOptionErrors tests that errors of custom options are kept unchanged.
+func TestCustomOptionErrors(t *testing.T) {
+	errCustom := errors.New("custom option error")
+
+	custom := func(v url.Values) error {
+		return fmt.Errorf("preset: %w", errCustom)
+	}
+
+	if err := setOptions(url.Values{}, custom); !errors.Is(err, errCustom) {
+		t.Errorf("setOptions() error = %v, want %v", err, errCustom)
+	}
+	if err := ValidateOptions(OptionType("png"), custom); !errors.Is(err, errCustom) {
+		t.Errorf("ValidateOptions() error = %v, want %v", err, errCustom)
+	}
+
+	err := ValidateOptions(custom, OptionWidth(50))
+
+	var errs optionErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("ValidateOptions() error = %v, want 2 errors", err)
+	}
+
+	// the list is matched without Unwrap() []error, which is supported since Go 1.20 only
+	var argErr *ArgError
+	if !errors.As(err, &argErr) || argErr.Name != "width" {
+		t.Errorf("errors.As(*ArgError) = %v, want the width error", argErr)
+	}
+	if !errors.Is(err, errCustom) {
+		t.Errorf("errors.Is(%v) = false, want true", errCustom)
+	}
+
+	// the wrapping context of *ArgError returned by a custom option is kept
+	wrapped := func(v url.Values) error {
+		return fmt.Errorf("preset: %w", &ArgError{"width", "is too small"})
+	}
+
+	err = setOptions(url.Values{}, wrapped)
+	if err == nil || err.Error() != `preset: invalid argument: "width" is too small` {
+		t.Errorf("setOptions() error = %v, want the wrapped error", err)
+	}
+	if !errors.As(err, &argErr) || argErr.Name != "width" {
+		t.Errorf("errors.As(*ArgError) = %v, want the width error", argErr)
+	}
+}
+
+// TestApplyOptions tests that options are applied to the accumulated values.
+func TestApplyOptions(t *testing.T) {
+	double := func(v url.Values) error {
+		width, err := strconv.Atoi(v.Get("width"))
+		if err != nil {
+			return &ArgError{"width", "is not set"}
+		}
+		v.Set("width", strconv.Itoa(2*width))
+		return nil
+	}
+
+	failing := func(v url.Values) error {
+		v.Del("width")
+		v.Set("height", "1")
+		return errors.New("failed")
+	}
+
+	v := url.Values{}
+
+	errs, custom := applyOptions(v, false, OptionWidth(400), double, failing)
+	if len(errs) != 0 || len(custom) != 1 {
+		t.Errorf("applyOptions() = %v, %v, want 1 custom error", errs, custom)
+	}
+
+	want := url.Values{"width": {"800"}}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("applyOptions() values = %v, want %v", v, want)
+	}
+
+	// the conflicting value isn't applied in the strict mode
+	v = url.Values{}
+
+	errs, _ = applyOptions(v, true, OptionWidth(400), double)
+	if len(errs) != 1 || errs[0].Name != "width" || v.Get("width") != "400" {
+		t.Errorf("applyOptions() = %v, values = %v, want the width conflict", errs, v)
+	}
+}